@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	_ "time/tzdata" // База часовых поясов для --timezone на системах без нее.
 
 	"github.com/es-debug/backend-academy-2024-go-template/internal/application"
 )
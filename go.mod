@@ -3,6 +3,7 @@ module github.com/es-debug/backend-academy-2024-go-template
 go 1.22.6
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/spf13/cobra v1.8.1
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/net v0.31.0
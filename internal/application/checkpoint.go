@@ -0,0 +1,81 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/parser"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/archive"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/file"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/network"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/state"
+)
+
+// StateOptions описывает настройки, от которых зависит накопленная в файле состояния статистика.
+// Если при следующем запуске они изменятся, сохраненные позиции чтения будут сброшены.
+func StateOptions(from, to string, readerOptions ReaderOptions) string {
+	timezone := ""
+	if readerOptions.Statistics.Location != nil {
+		timezone = readerOptions.Statistics.Location.String()
+	}
+
+	return fmt.Sprintf("from=%s to=%s filter-field=%s filter-value=%s log-format=%s json-field=%s group-by=%s "+
+		"timezone=%s keep-query=%t relative-error=%g top-k=%d bucket=%s", from, to, readerOptions.FilterField, readerOptions.FilterValue,
+		readerOptions.Format, strings.Join(readerOptions.JSONFields, ","), readerOptions.Statistics.GroupBy, timezone,
+		readerOptions.Statistics.KeepQuery, readerOptions.Statistics.RelativeError, readerOptions.Statistics.TopK,
+		readerOptions.Statistics.Bucket)
+}
+
+// checkpointable проверяет, можно ли сохранить позицию чтения входных данных по пути path.
+// Стандартный ввод и архивы всегда читаются целиком.
+func checkpointable(path string) bool {
+	return path != StdinPath && (IsURL(path) || !archive.IsArchive(path))
+}
+
+func chooseResumeReader(path string, position input.Position, options ReaderOptions) (input.Checkpointer, error) {
+	if IsURL(path) {
+		return network.ResumeReader(path, position, options.LineParser(), options.Network)
+	}
+
+	return file.ResumeLogReader(path, position, options.LineParser())
+}
+
+// collectCheckpoint дочитывает входные данные с сохраненной позиции и возвращает
+// накопленную по ним статистику вместе с новой позицией чтения.
+// Если данные были усечены или заменены, статистика собирается заново.
+func collectCheckpoint(path string, saved *state.Checkpoint, readerOptions ReaderOptions,
+	from, to time.Time) (*analyzer.Statistics, *state.Checkpoint, error) {
+	reader, err := chooseResumeReader(path, saved.Position, readerOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	added := analyzer.NewStatistics(readerOptions.Statistics)
+
+	err = parser.Run(reader, DisplayName(path), from, to, added, readerOptions.Errors)
+	position, positionErr := reader.Position()
+
+	if err = errors.Join(err, positionErr, reader.Close()); err != nil {
+		return nil, nil, err
+	}
+
+	shard := analyzer.NewStatistics(readerOptions.Statistics)
+	shard.Files = append(shard.Files, DisplayName(path))
+	shard.Formats[DisplayName(path)] = readerFormat(reader, readerOptions)
+
+	if reader.Resumed() && saved.Statistics != nil {
+		shard.Merge(saved.Statistics)
+	}
+
+	shard.Merge(added)
+
+	// Список файлов и их форматы в файле состояния не нужны, они заново собираются при каждом запуске.
+	cumulative := *shard
+	cumulative.Files, cumulative.Formats = nil, nil
+
+	return shard, &state.Checkpoint{Position: position, Statistics: &cumulative}, nil
+}
@@ -1,60 +1,196 @@
 package application
 
 import (
-	"net/http"
-	"path/filepath"
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
 	"sort"
 	"strings"
 
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/sketch"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/parser"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/archive"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/file"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/network"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/stdin"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
+	"github.com/es-debug/backend-academy-2024-go-template/pkg/util"
+)
+
+const (
+	StdinPath = "-"       // Путь, означающий чтение логов из стандартного ввода.
+	StdinName = "<stdin>" // Имя стандартного ввода в отчете.
+)
+
+var (
+	ErrNoFilesMatched = errors.New("no files match the path")
 )
 
 type Number interface {
 	~int | int8 | int16 | int32 | int64 | uint | uint8 | uint16 | uint32 | uint64 | float32 | float64
 }
 
-func chooseReader(path, field, pattern string) (input.LogReader, error) {
-	if IsURL(path) {
-		return network.NewReader(path, field, pattern)
+// ReaderOptions содержит настройки, необходимые для создания input.LogReader.
+type ReaderOptions struct {
+	FilterField string               // Поле, по которому фильтруются логи.
+	FilterValue string               // Значение, по которому фильтруются логи.
+	Network     network.Options      // Настройки чтения логов по сети.
+	ArchiveGlob string               // Шаблон имен файлов внутри архивов, пустой — все файлы.
+	Format      string               // Формат логов: имя встроенного формата или директива nginx log_format / Apache LogFormat.
+	JSONFields  []string             // Сопоставление полей записи ключам JSON для формата "json".
+	Parser      log.Parser           // Parser, скомпилированный из Format.
+	Statistics  analyzer.Options     // Настройки собираемой статистики.
+	Errors      *parser.ErrorHandler // Обработка строчек, которые не удалось разобрать, nil — прервать обработку.
+}
+
+// LineParser возвращает параметры разбора и фильтрации строчек для input.LogReader.
+func (o ReaderOptions) LineParser() impl.LineParser {
+	format := o.Format
+	if format == "" {
+		format = log.CombinedFormat
+	}
+
+	return impl.LineParser{
+		Parser:  o.Parser,
+		Field:   o.FilterField,
+		Pattern: o.FilterValue,
+		Format:  format,
+	}
+}
+
+// readerFormat возвращает имя формата лога, который читает reader.
+func readerFormat(reader input.LogReader, options ReaderOptions) string {
+	if formatReader, ok := reader.(input.FormatReader); ok {
+		return formatReader.Format()
+	}
+
+	return options.LineParser().Format
+}
+
+type readerFactory = func(path string) (input.LogReader, error)
+
+// visitInputs открывает входные данные по пути path и передает в visit каждый лог из них вместе с его именем в отчете.
+// Архив содержит по логу на каждый подходящий файл внутри него, остальные пути — один лог, открытый через open.
+func visitInputs(path string, options ReaderOptions, open readerFactory,
+	visit func(name string, reader input.LogReader) error) error {
+	if !IsURL(path) && archive.IsArchive(path) {
+		return archive.Walk(path, options.ArchiveGlob, options.LineParser(),
+			func(name string, reader *archive.Reader) error {
+				return visit(path+archive.EntrySeparator+name, reader)
+			})
+	}
+
+	reader, err := open(path)
+	if err != nil {
+		return err
 	}
 
-	return file.NewLogReader(path, field, pattern)
+	err = visit(DisplayName(path), reader)
+
+	return errors.Join(err, reader.Close())
 }
 
-// GetPaths возвращает список путей файлов, соответствующих переданному пути.
-// Если путь является URL, проверяется его доступность и он возвращается как единственный элемент списка.
-// Если это glob-паттерн, возвращаются пути, соответствующие этому паттерну.
-func GetPaths(path string) ([]string, error) {
+func chooseReader(path string, options ReaderOptions) (input.LogReader, error) {
+	if path == StdinPath {
+		return stdin.NewReader(options.LineParser())
+	}
+
 	if IsURL(path) {
-		if err := CheckURL(path); err != nil {
+		return network.NewReader(path, options.LineParser(), options.Network)
+	}
+
+	return file.NewLogReader(path, options.LineParser())
+}
+
+// GetPaths возвращает список путей файлов, соответствующих переданным путям.
+// Если путь является URL, проверяется его доступность и он возвращается как есть.
+// Путь "-" означает стандартный ввод и возвращается как есть.
+// Если это glob-паттерн (в том числе с рекурсивным "**") или директория, возвращаются пути
+// соответствующих ему файлов, кроме тех, что подходят под один из шаблонов excludes.
+// Возвращает ErrNoFilesMatched, если какому-то паттерну не соответствует ни один файл.
+// Пути, которые встречаются несколько раз, возвращаются один раз.
+func GetPaths(paths, excludes []string, options network.Options) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	seen := make(map[string]bool)
+
+	for _, path := range paths {
+		matches, err := getPaths(path, excludes, options)
+		if err != nil {
 			return nil, err
 		}
 
-		return []string{path}, nil
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+
+				files = append(files, match)
+			}
+		}
 	}
 
-	return filepath.Glob(path)
+	return files, nil
 }
 
-// CheckURL проверяет доступность URL, отправляя HTTP-запрос методом GET.
-// Возвращает ошибку, если URL недоступен или запрос не удался.
-func CheckURL(url string) error {
-	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+func getPaths(path string, excludes []string, options network.Options) ([]string, error) {
+	if path == StdinPath {
+		return []string{path}, nil
+	}
 
-	if err != nil {
-		return err
+	if IsURL(path) {
+		if err := CheckURL(path, options); err != nil {
+			return nil, err
+		}
+
+		return []string{path}, nil
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	matches, err := util.Glob(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer resp.Body.Close()
+	files := make([]string, 0, len(matches))
 
-	return nil
+	for _, match := range matches {
+		if !isExcluded(match, excludes) {
+			files = append(files, match)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoFilesMatched, path)
+	}
+
+	return files, nil
+}
+
+func isExcluded(path string, excludes []string) bool {
+	for _, exclude := range excludes {
+		if util.MatchPath(exclude, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckURL проверяет доступность URL, отправляя HTTP-запрос методом HEAD.
+// Возвращает ошибку, если URL недоступен или запрос не удался.
+func CheckURL(url string, options network.Options) error {
+	return network.Probe(url, options)
+}
+
+// DisplayName возвращает имя входных данных в том виде, в котором оно выводится в отчете.
+func DisplayName(path string) string {
+	if path == StdinPath {
+		return StdinName
+	}
+
+	return path
 }
 
 // IsURL проверяет, является ли переданный путь URL-адресом.
@@ -64,42 +200,47 @@ func IsURL(path string) bool {
 }
 
 // SortMapByValues сортирует ключи по убыванию их значений.
-func SortMapByValues[T Number, V comparable](m map[V]T) (keys []V) {
+// Ключи с одинаковыми значениями сортируются по возрастанию, чтобы результат не зависел от порядка обхода мапы.
+func SortMapByValues[T Number, V cmp.Ordered](m map[V]T) (keys []V) {
 	keys = make([]V, 0, len(m))
 
 	for k := range m {
 		keys = append(keys, k)
 	}
 
-	sort.SliceStable(keys, func(i, j int) bool {
-		return m[keys[i]] > m[keys[j]]
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+
+		return keys[i] < keys[j]
 	})
 
 	return keys
 }
 
-// Percentile вычисляет значение указанного перцентиля для переданных значений.
-// Значения сортируются, и результат интерполируется между ближайшими элементами.
-func Percentile(values []int, percentile int) int {
-	if len(values) == 0 {
-		return 0
-	}
-
-	if percentile < 0 || percentile > 100 {
-		return 0
+// Percentile вычисляет значение указанного перцентиля по распределению distribution.
+// Погрешность результата не больше относительной погрешности distribution.
+// Возвращает ErrInvalidPercentile, если перцентиль не лежит в диапазоне от 0 до 100.
+func Percentile(distribution *sketch.Sketch, percentile float64) (float64, error) {
+	if percentile < 0 || percentile > 100 || math.IsNaN(percentile) {
+		return 0, fmt.Errorf("%w: %g", ErrInvalidPercentile, percentile)
 	}
 
-	sortedData := make([]int, len(values))
-	copy(sortedData, values)
+	return distribution.Quantile(percentile / 100), nil
+}
 
-	index := int(float64(percentile) / 100 * float64(len(sortedData)-1))
-	lowerIndex := index
-	upperIndex := lowerIndex + 1
+// ValidatePercentiles проверяет, что передан хотя бы один перцентиль и все они лежат в диапазоне от 0 до 100.
+func ValidatePercentiles(percentiles []float64) error {
+	if len(percentiles) == 0 {
+		return ErrNoPercentiles
+	}
 
-	if upperIndex < len(sortedData) {
-		weight := float64(index) - float64(lowerIndex)
-		return int(float64(sortedData[lowerIndex])*(1-weight) + float64(sortedData[upperIndex])*weight)
+	for _, percentile := range percentiles {
+		if percentile < 0 || percentile > 100 || math.IsNaN(percentile) {
+			return fmt.Errorf("%w: %g", ErrInvalidPercentile, percentile)
+		}
 	}
 
-	return sortedData[lowerIndex]
+	return nil
 }
@@ -2,6 +2,9 @@ package application
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"math/big"
 	"os"
 	"sort"
@@ -14,6 +17,8 @@ import (
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/parser"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/visual"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/network"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/state"
 	"github.com/spf13/cobra"
 )
 
@@ -26,67 +31,309 @@ const (
 
 var (
 	ErrUndefinedFlagValueType = errors.New("undefined flag value")
+	ErrInvalidRefresh         = errors.New("refresh interval must be positive")
+	ErrStateWithFollow        = errors.New("state file cannot be used in follow mode")
+	ErrInvalidRelativeError   = errors.New("relative error must be between 0 and 1")
+	ErrInvalidPercentile      = errors.New("percentile must be between 0 and 100")
+	ErrNoPercentiles          = errors.New("at least one percentile is required")
+	ErrInvalidTopK            = errors.New("top-k must not be negative")
+	ErrInvalidBucket          = errors.New("bucket must be one of 1m, 5m, 1h, 1d")
+
+	// buckets сопоставляет значениям --bucket длину интервала.
+	buckets = map[string]time.Duration{
+		"1m": time.Minute,
+		"5m": 5 * time.Minute,
+		"1h": time.Hour,
+		"1d": 24 * time.Hour,
+	}
 )
 
 // ProcessFlags обрабатывает мапу флагов и возвращает
-// Список файлов (files), фильтры (filterField и filterValue),
-// Перцентиль (percentile) и error, если что-то пошло не так.
-func ProcessFlags(flagsMap FlagsMap) (files []string, filterField, filterValue string, percentile int, err error) {
-	path, _ := flagsMap[flags.Path].GetString()
+// Список файлов (files), настройки чтения логов (readerOptions),
+// Перцентили (percentiles) и error, если что-то пошло не так.
+func ProcessFlags(flagsMap FlagsMap) (files []string, readerOptions ReaderOptions, percentiles []float64, err error) {
+	readerOptions.Network = NetworkOptions(flagsMap)
+
+	paths, _ := flagsMap[flags.Path].GetStringArray()
+	excludes, _ := flagsMap[flags.Exclude].GetStringArray()
+
+	files, err = GetPaths(paths, excludes, readerOptions.Network)
+
+	readerOptions.FilterField, _ = flagsMap[flags.FilterField].GetString()
+
+	readerOptions.FilterValue, _ = flagsMap[flags.FilterValue].GetString()
+
+	readerOptions.ArchiveGlob, _ = flagsMap[flags.ArchiveGlob].GetString()
+
+	readerOptions.Format, _ = flagsMap[flags.LogFormat].GetString()
+
+	readerOptions.JSONFields, _ = flagsMap[flags.JSONField].GetStringSlice()
+
+	readerOptions.Statistics.GroupBy, _ = flagsMap[flags.GroupBy].GetString()
+
+	readerOptions.Statistics.KeepQuery, _ = flagsMap[flags.KeepQuery].GetBool()
+
+	readerOptions.Statistics.RelativeError, _ = flagsMap[flags.RelativeError].GetFloat()
+
+	if relativeError := readerOptions.Statistics.RelativeError; err == nil && (relativeError <= 0 || relativeError >= 1) {
+		err = ErrInvalidRelativeError
+	}
+
+	readerOptions.Statistics.TopK, _ = flagsMap[flags.TopK].GetInt()
+
+	if err == nil && readerOptions.Statistics.TopK < 0 {
+		err = ErrInvalidTopK
+	}
+
+	var bucketErr error
+
+	bucket, _ := flagsMap[flags.Bucket].GetString()
+	readerOptions.Statistics.Bucket, bucketErr = ParseBucket(bucket)
+
+	if err == nil {
+		err = bucketErr
+	}
+
+	if err == nil {
+		readerOptions.Parser, err = log.NewParser(readerOptions.Format, readerOptions.JSONFields)
+	}
+
+	percentiles, _ = flagsMap[flags.Percentile].GetFloatSlice()
 
-	files, err = GetPaths(path)
+	if err == nil {
+		err = ValidatePercentiles(percentiles)
+	}
 
-	filterField, _ = flagsMap[flags.FilterField].GetString()
+	return files, readerOptions, percentiles, err
+}
 
-	filterValue, _ = flagsMap[flags.FilterValue].GetString()
+// ParseBucket возвращает длину интервала трафика по значению флага --bucket.
+// Пустое значение означает, что трафик по времени не считается. Возвращает ErrInvalidBucket для неизвестного значения.
+func ParseBucket(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
 
-	percentile, _ = flagsMap[flags.Percentile].GetInt()
+	bucket, ok := buckets[value]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBucket, value)
+	}
 
-	return files, filterField, filterValue, percentile, err
+	return bucket, nil
 }
 
-// ProcessFiles обрабатывает список файлов, применяет фильтры и собирает статистику.
-func ProcessFiles(files []string, filterField, filterValue string,
-	from, to time.Time, percentile int, stats *analyzer.Statistics) error {
-	for _, file := range files {
-		reader, err := chooseReader(file, filterField, filterValue)
+// NetworkOptions собирает настройки сетевого чтения логов из флагов.
+func NetworkOptions(flagsMap FlagsMap) network.Options {
+	connectTimeout, _ := flagsMap[flags.ConnectTimeout].GetInt()
+	readTimeout, _ := flagsMap[flags.ReadTimeout].GetInt()
+	retries, _ := flagsMap[flags.Retries].GetInt()
 
-		if err != nil {
-			return err
-		}
+	return network.Options{
+		ConnectTimeout: time.Duration(connectTimeout) * time.Second,
+		ReadTimeout:    time.Duration(readTimeout) * time.Second,
+		Retries:        retries,
+	}
+}
 
-		if err := parser.Run(reader, from, to, stats); err != nil {
-			return err
-		}
+// ProcessFiles обрабатывает список файлов на workers горутинах, применяет фильтры и собирает статистику.
+// Результат не зависит от количества воркеров. Если передано сохраненное состояние saved,
+// файлы дочитываются с сохраненных позиций, статистика получается накопительной,
+// а в saved записываются новые позиции.
+func ProcessFiles(files []string, readerOptions ReaderOptions, from, to time.Time,
+	percentiles []float64, workers int, saved *state.State, stats *analyzer.Statistics) error {
+	shards, err := collectShards(files, readerOptions, from, to, Workers(workers), saved)
+	if err != nil {
+		return err
+	}
+
+	for _, shard := range shards {
+		stats.Merge(shard)
 	}
 
+	Summarize(stats, percentiles)
+
+	return nil
+}
+
+// Summarize подсчитывает итоговые значения статистики: общее количество запросов,
+// порядок отображения ключей, размеры запросов и перцентиль.
+// Может вызываться повторно по мере накопления статистики.
+func Summarize(stats *analyzer.Statistics, percentiles []float64) {
 	stats.TotalRequestsNumber = big.NewInt(0)
 
-	for _, cnt := range stats.ResourcesCount.Values {
+	for _, cnt := range stats.RequestsCount.Values {
 		stats.TotalRequestsNumber.Add(stats.TotalRequestsNumber, big.NewInt(int64(cnt)))
 	}
 
+	stats.ResourcesCount.Estimate()
+	stats.IPCount.Estimate()
+	stats.Cardinality.Estimate()
+
 	stats.ResourcesCount.KeysOrder = SortMapByValues(stats.ResourcesCount.Values)
 	stats.RequestsCount.KeysOrder = SortMapByValues(stats.RequestsCount.Values)
 	stats.IPCount.KeysOrder = SortMapByValues(stats.IPCount.Values)
+	stats.GroupBy.KeysOrder = SortMapByValues(stats.GroupBy.Values)
+	stats.Methods.KeysOrder = SortMapByValues(stats.Methods.Requests())
+	stats.Protocols.KeysOrder = SortMapByValues(stats.Protocols.Values)
 
 	if stats.TotalRequestsNumber.Int64() != 0 {
-		stats.AverageRequestNumber = stats.ByteSize.Div(stats.ByteSize,
+		stats.AverageRequestNumber = new(big.Int).Div(stats.ByteSize,
 			stats.TotalRequestsNumber)
 	} else {
 		stats.AverageRequestNumber = big.NewInt(0)
 	}
 
-	sort.Ints(stats.ByteSizes)
+	stats.MinSizeRequest = int(stats.Sizes.Min)
+	stats.MaxSizeRequest = int(stats.Sizes.Max)
+	stats.PercentileRanks = percentiles
+	stats.Percentiles = make([]int, len(percentiles))
 
-	if len(stats.ByteSizes) > 0 {
-		stats.MinSizeRequest = stats.ByteSizes[0]
-		stats.MaxSizeRequest = stats.ByteSizes[len(stats.ByteSizes)-1]
+	for i, percentile := range percentiles {
+		size, _ := Percentile(stats.Sizes, percentile)
+		stats.Percentiles[i] = int(math.Round(size))
 	}
 
-	stats.Percentile = Percentile(stats.ByteSizes, percentile)
+	SummarizeLatency(&stats.Latency, stats.ResourcesCount.KeysOrder, percentiles)
+	SummarizeTraffic(&stats.Traffic, stats.Options.Location)
+	SummarizeStatus(&stats.Status, stats.RequestsCount.Values)
+}
 
-	return nil
+// SummarizeStatus подсчитывает количество запросов по классам кодов ответа и доли ошибок по количеству
+// запросов по кодам ответа codes, а также упорядочивает ресурсы по количеству ошибок.
+func SummarizeStatus(status *analyzer.StatusStats, codes map[log.ResponseCode]int) {
+	status.Classes = make(map[string]int)
+	total := 0
+
+	for code, cnt := range codes {
+		status.Classes[log.HTTPStatus{Code: code}.Class()] += cnt
+		total += cnt
+	}
+
+	status.ClassesOrder = status.ClassesOrder[:0]
+	for class := range status.Classes {
+		status.ClassesOrder = append(status.ClassesOrder, class)
+	}
+
+	sort.Strings(status.ClassesOrder)
+
+	status.ClientErrorRate, status.ServerErrorRate = 0, 0
+
+	if total != 0 {
+		status.ClientErrorRate = float64(status.Classes["4xx"]) * 100 / float64(total)
+		status.ServerErrorRate = float64(status.Classes["5xx"]) * 100 / float64(total)
+	}
+
+	status.ErrorRate = status.ClientErrorRate + status.ServerErrorRate
+
+	status.ClientErrors.Estimate()
+	status.ServerErrors.Estimate()
+
+	status.ClientErrors.KeysOrder = SortMapByValues(status.ClientErrors.Values)
+	status.ServerErrors.KeysOrder = SortMapByValues(status.ServerErrors.Values)
+}
+
+// SummarizeLatency подсчитывает итоговые значения времени ответа: общие, по ресурсам и по классам кодов ответа.
+// Ресурсы выводятся в порядке resourcesOrder (как в таблице ресурсов), классы кодов ответа — по возрастанию.
+func SummarizeLatency(latency *analyzer.LatencyStats, resourcesOrder []string, percentiles []float64) {
+	summarizeLatency(&latency.Total, percentiles)
+
+	latency.ResourcesOrder = latency.ResourcesOrder[:0]
+
+	for _, resource := range resourcesOrder {
+		if resourceLatency, ok := latency.Resources[resource]; ok {
+			summarizeLatency(resourceLatency, percentiles)
+
+			latency.ResourcesOrder = append(latency.ResourcesOrder, resource)
+		}
+	}
+
+	latency.StatusClassesOrder = latency.StatusClassesOrder[:0]
+
+	for class, classLatency := range latency.StatusClasses {
+		summarizeLatency(classLatency, percentiles)
+
+		latency.StatusClassesOrder = append(latency.StatusClassesOrder, class)
+	}
+
+	sort.Strings(latency.StatusClassesOrder)
+}
+
+// summarizeLatency подсчитывает минимальное, максимальное и среднее время ответа группы запросов и его перцентили.
+func summarizeLatency(latency *analyzer.Latency, percentiles []float64) {
+	latency.Min = latency.Distribution.Min
+	latency.Max = latency.Distribution.Max
+	latency.Mean = latency.Distribution.Mean()
+	latency.Percentiles = make([]float64, len(percentiles))
+
+	for i, percentile := range percentiles {
+		latency.Percentiles[i], _ = Percentile(latency.Distribution, percentile)
+	}
+}
+
+// SummarizeTraffic строит ряд трафика по всем интервалам окна traffic.From–traffic.To, выровненным
+// по часовому поясу location, в том числе по интервалам без запросов, и находит самый загруженный и самый тихий из них.
+// Если окно не задано или делится больше чем на analyzer.MaxTrafficBuckets интервалов, ряд строится
+// от первого до последнего интервала с запросами.
+func SummarizeTraffic(traffic *analyzer.Traffic, location *time.Location) {
+	traffic.Series = traffic.Series[:0]
+	traffic.Peak, traffic.Quietest = 0, 0
+
+	if traffic.Bucket <= 0 {
+		return
+	}
+
+	var first, last time.Time
+
+	if !traffic.From.IsZero() && !traffic.To.IsZero() &&
+		traffic.To.Sub(traffic.From)/traffic.Bucket < analyzer.MaxTrafficBuckets {
+		first = analyzer.BucketStart(traffic.From, traffic.Bucket, location)
+		last = analyzer.BucketStart(traffic.To, traffic.Bucket, location)
+	} else {
+		if len(traffic.Buckets) == 0 {
+			return
+		}
+
+		for _, bucket := range traffic.Buckets {
+			if first.IsZero() || bucket.Start.Before(first) {
+				first = bucket.Start
+			}
+
+			if last.IsZero() || bucket.Start.After(last) {
+				last = bucket.Start
+			}
+		}
+
+		first = analyzer.BucketStart(first, traffic.Bucket, location)
+		last = analyzer.BucketStart(last, traffic.Bucket, location)
+	}
+
+	for start := first; !start.After(last); start = analyzer.NextBucket(start, traffic.Bucket) {
+		bucket := analyzer.TrafficBucket{Start: start}
+		if counted, ok := traffic.Buckets[start.Unix()]; ok {
+			bucket = *counted
+			bucket.Start = start
+		}
+
+		traffic.Series = append(traffic.Series, bucket)
+
+		if bucket.Requests > traffic.Series[traffic.Peak].Requests {
+			traffic.Peak = len(traffic.Series) - 1
+		}
+
+		if bucket.Requests < traffic.Series[traffic.Quietest].Requests {
+			traffic.Quietest = len(traffic.Series) - 1
+		}
+	}
+}
+
+// ReportTime возвращает время t для отчета: дата без времени выводится как есть (она уже относится
+// к часовому поясу location), а время с указанным смещением переводится в location.
+func ReportTime(timeString string, t time.Time, location *time.Location) string {
+	if iso.IsDate(timeString) {
+		return timeString
+	}
+
+	return t.In(location).Format(time.RFC3339)
 }
 
 // WriteStatistics записывает статистику в указанный формат (Markdown или AsciiDoc).
@@ -109,53 +356,101 @@ func WriteStatistics(dir, filename, format string, stats *analyzer.Statistics) e
 func GetStatistics(flagsMap FlagsMap) error {
 	fromString, _ := flagsMap[flags.From].GetString()
 	toString, _ := flagsMap[flags.To].GetString()
+	timezone, _ := flagsMap[flags.Timezone].GetString()
 
-	from, err := iso.ParseTime(fromString)
+	location, err := iso.ParseLocation(timezone)
 	if err != nil {
 		return err
 	}
 
-	to, err := iso.ParseTime(toString)
+	from, err := iso.ParseTimeIn(fromString, location)
 	if err != nil {
 		return err
 	}
 
-	files, filterField, filterValue, percentile, err := ProcessFlags(flagsMap)
+	to, err := iso.ParseTimeIn(toString, location)
 	if err != nil {
 		return err
 	}
 
-	stats := &analyzer.Statistics{
-		Files: files,
-		From:  fromString,
-		To:    toString,
-		RequestsCount: analyzer.RequestsCount{
-			Values:    make(map[log.ResponseCode]int),
-			KeysOrder: []log.ResponseCode{},
-		},
-		ResourcesCount: analyzer.ResourcesCount{
-			Values:    make(map[string]int),
-			KeysOrder: []string{},
-		},
-		IPCount: analyzer.IPCount{
-			Values:    make(map[string]int),
-			KeysOrder: []string{},
-		},
-		MinSizeRequest: 0,
-		MaxSizeRequest: 0,
-		ByteSizes:      make([]int, 0),
-		ByteSize:       big.NewInt(0),
+	files, readerOptions, percentiles, err := ProcessFlags(flagsMap)
+	if err != nil {
+		return err
+	}
+
+	readerOptions.Statistics.Location = location
+
+	onError, _ := flagsMap[flags.OnError].GetString()
+
+	var quarantine io.Writer
+
+	if onError == parser.OnErrorQuarantine {
+		quarantinePath, _ := flagsMap[flags.QuarantineFile].GetString()
+
+		file, err := os.Create(quarantinePath)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		quarantine = file
 	}
 
-	if err := ProcessFiles(files, filterField, filterValue, from, to, percentile, stats); err != nil {
+	if readerOptions.Errors, err = parser.NewErrorHandler(onError, quarantine); err != nil {
 		return err
 	}
 
+	stats := analyzer.NewStatistics(readerOptions.Statistics)
+	stats.From = ReportTime(fromString, from, location)
+	stats.To = ReportTime(toString, to, location)
+	stats.Traffic.From, stats.Traffic.To = from, to
+
 	dir, _ := flagsMap[flags.Directory].GetString()
 	filename, _ := flagsMap[flags.Filename].GetString()
 	format, _ := flagsMap[flags.Format].GetString()
 
-	return WriteStatistics(dir, filename, format, stats)
+	stateFile, _ := flagsMap[flags.StateFile].GetString()
+
+	if follow, _ := flagsMap[flags.Follow].GetBool(); follow {
+		if stateFile != "" {
+			return ErrStateWithFollow
+		}
+
+		refresh, _ := flagsMap[flags.Refresh].GetInt()
+		if refresh <= 0 {
+			return ErrInvalidRefresh
+		}
+
+		return FollowFiles(files, readerOptions, from, to, percentiles, stats, time.Duration(refresh)*time.Second,
+			func(stats *analyzer.Statistics) error {
+				return WriteStatistics(dir, filename, format, stats)
+			})
+	}
+
+	workers, _ := flagsMap[flags.Workers].GetInt()
+
+	var saved *state.State
+
+	if stateFile != "" {
+		if saved, err = state.Load(stateFile, StateOptions(fromString, toString, readerOptions)); err != nil {
+			return err
+		}
+	}
+
+	if err := ProcessFiles(files, readerOptions, from, to, percentiles, workers, saved, stats); err != nil {
+		return err
+	}
+
+	if err := WriteStatistics(dir, filename, format, stats); err != nil {
+		return err
+	}
+
+	if saved != nil {
+		return saved.Save(stateFile)
+	}
+
+	return nil
 }
 
 // Run создает cobra-комманду analyzer (обертка над pflag), добавляет все флаги и запускает ее.
@@ -197,6 +492,46 @@ func Run() error {
 				flagValue.DefaultValue(),
 				flag.Use,
 			)
+		case *flags.StringSliceValue:
+			analyzerCmd.Flags().StringSliceVarP(
+				flagValue.Pointer(),
+				flag.Name,
+				flag.ShorthandName,
+				flagValue.DefaultValue(),
+				flag.Use,
+			)
+		case *flags.BoolValue:
+			analyzerCmd.Flags().BoolVarP(
+				flagValue.Pointer(),
+				flag.Name,
+				flag.ShorthandName,
+				flagValue.DefaultValue(),
+				flag.Use,
+			)
+		case *flags.FloatValue:
+			analyzerCmd.Flags().Float64VarP(
+				flagValue.Pointer(),
+				flag.Name,
+				flag.ShorthandName,
+				flagValue.DefaultValue(),
+				flag.Use,
+			)
+		case *flags.FloatSliceValue:
+			analyzerCmd.Flags().Float64SliceVarP(
+				flagValue.Pointer(),
+				flag.Name,
+				flag.ShorthandName,
+				flagValue.DefaultValue(),
+				flag.Use,
+			)
+		case *flags.StringArrayValue:
+			analyzerCmd.Flags().StringArrayVarP(
+				flagValue.Pointer(),
+				flag.Name,
+				flag.ShorthandName,
+				flagValue.DefaultValue(),
+				flag.Use,
+			)
 		default:
 			return ErrUndefinedFlagValueType
 		}
@@ -0,0 +1,160 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/parser"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/file"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
+)
+
+const (
+	followPollInterval = 500 * time.Millisecond
+)
+
+// followInput сообщает, что index-й путь начал читать лог с именем name в формате format.
+type followInput struct {
+	index  int
+	name   string
+	format string
+}
+
+func chooseFollowReader(ctx context.Context, path string, options ReaderOptions) (input.LogReader, error) {
+	if path == StdinPath || IsURL(path) {
+		return chooseReader(path, options)
+	}
+
+	return file.NewTailReader(ctx, path, options.LineParser(), followPollInterval)
+}
+
+// followRecord это прочитанная строчка номер line лога name: запись или ошибка разбора.
+type followRecord struct {
+	record   *log.Record
+	name     string
+	line     int64
+	parseErr *log.ParseError
+}
+
+// FollowFiles читает файлы так же, как `tail -F`, и собирает статистику по мере появления новых строчек.
+// Каждые refresh статистика подытоживается и передается в write.
+// Работа завершается по SIGINT или SIGTERM, либо когда все входные данные закончились
+// (например, при чтении из стандартного ввода), после чего статистика записывается в последний раз.
+func FollowFiles(files []string, readerOptions ReaderOptions, from, to time.Time, percentiles []float64,
+	stats *analyzer.Statistics, refresh time.Duration, write func(stats *analyzer.Statistics) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	records := make(chan followRecord)
+	inputs := make(chan followInput)
+	errs := make(chan error, len(files))
+
+	open := func(path string) (input.LogReader, error) {
+		return chooseFollowReader(ctx, path, readerOptions)
+	}
+
+	wg := sync.WaitGroup{}
+
+	for index, path := range files {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := visitInputs(path, readerOptions, open, func(name string, reader input.LogReader) error {
+				select {
+				case inputs <- followInput{index: index, name: name, format: readerFormat(reader, readerOptions)}:
+				case <-ctx.Done():
+					return nil
+				}
+
+				return sendRecords(ctx, reader, name, records)
+			})
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	// Имена логов хранятся отдельно для каждого пути, чтобы порядок в отчете не зависел от порядка запуска горутин.
+	names := make([][]string, len(files))
+
+	for {
+		select {
+		case in := <-inputs:
+			names[in.index] = append(names[in.index], in.name)
+			stats.Formats[in.name] = in.format
+			stats.Files = slices.Concat(names...)
+		case record := <-records:
+			if record.parseErr == nil {
+				parser.Collect(record.record, from, to, stats)
+				continue
+			}
+
+			if err := readerOptions.Errors.Handle(record.name, record.line, record.parseErr, stats); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			Summarize(stats, percentiles)
+
+			if err := write(stats); err != nil {
+				return err
+			}
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			Summarize(stats, percentiles)
+			return write(stats)
+		case <-done:
+			Summarize(stats, percentiles)
+			return write(stats)
+		}
+	}
+}
+
+// sendRecords читает строчки лога name из reader и передает их в records вместе с номером строчки.
+// Строчки, которые не удалось разобрать, передаются с ошибкой, чтобы их обработал сборщик статистики.
+func sendRecords(ctx context.Context, reader input.LogReader, name string, records chan<- followRecord) error {
+	line := int64(0)
+
+	for {
+		record, err := reader.Read()
+
+		var parseErr *log.ParseError
+
+		if err != nil && !errors.As(err, &parseErr) {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		line++
+
+		select {
+		case records <- followRecord{record: record, name: name, line: line, parseErr: parseErr}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
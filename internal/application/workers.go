@@ -0,0 +1,196 @@
+package application
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/parser"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/archive"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl/file"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/state"
+)
+
+const (
+	// minChunkSize это минимальный размер части файла, ради которой его имеет смысл делить.
+	minChunkSize = 16 << 20
+)
+
+// Workers возвращает количество воркеров для обработки файлов: 0 означает количество ядер процессора.
+func Workers(workers int) int {
+	if workers <= 0 {
+		return runtime.NumCPU()
+	}
+
+	return workers
+}
+
+// job это единица работы воркера: путь целиком или часть большого файла.
+type job struct {
+	path       string
+	chunk      *file.Chunk       // chunk равен nil, если путь обрабатывается целиком.
+	parser     impl.LineParser   // parser это формат, определенный для всего файла, если chunk не равен nil.
+	checkpoint *state.Checkpoint // checkpoint не равен nil, если чтение продолжается с сохраненной позиции.
+}
+
+// planJobs разбивает пути на задачи для воркеров. Большие несжатые файлы делятся на части (кроме режима --top-k),
+// выровненные по границам строчек, чтобы один файл можно было обрабатывать на нескольких горутинах.
+// Если передано сохраненное состояние saved, пути, для которых можно сохранить позицию чтения,
+// обрабатываются целиком, начиная с этой позиции. Формат лога определяется один раз для каждого разделенного файла,
+// чтобы все его части разбирались одинаково.
+func planJobs(files []string, readerOptions ReaderOptions, workers int, saved *state.State) ([]job, error) {
+	jobs := make([]job, 0, len(files))
+
+	for _, path := range files {
+		if saved != nil && checkpointable(path) {
+			checkpoint, ok := saved.Checkpoints[path]
+			if !ok {
+				checkpoint = &state.Checkpoint{}
+			}
+
+			jobs = append(jobs, job{path: path, checkpoint: checkpoint})
+
+			continue
+		}
+
+		// В приближенном режиме (--top-k) файлы не делятся: слияние сводок Space-Saving частей файла зависело бы
+		// от количества воркеров, а каждый файл целиком дает тот же результат, что и последовательная обработка.
+		if workers < 2 || readerOptions.Statistics.TopK > 0 || path == StdinPath || IsURL(path) ||
+			archive.IsArchive(path) {
+			jobs = append(jobs, job{path: path})
+			continue
+		}
+
+		chunks, err := file.Split(path, workers, minChunkSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chunks) == 0 {
+			jobs = append(jobs, job{path: path})
+			continue
+		}
+
+		lineParser, err := file.DetectFormat(path, readerOptions.LineParser())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, chunk := range chunks {
+			jobs = append(jobs, job{path: path, chunk: &chunk, parser: lineParser})
+		}
+	}
+
+	return jobs, nil
+}
+
+// collectShards обрабатывает файлы на workers горутинах. Статистика каждой задачи
+// собирается в отдельную часть (shard), части возвращаются в порядке files,
+// поэтому их слияние дает тот же результат, что и последовательная обработка.
+// Если передано сохраненное состояние saved, его позиции чтения заменяются новыми.
+// Если при обработке нескольких файлов произошли ошибки, возвращается ошибка первого из них.
+func collectShards(files []string, readerOptions ReaderOptions, from, to time.Time,
+	workers int, saved *state.State) ([]*analyzer.Statistics, error) {
+	jobs, err := planJobs(files, readerOptions, workers, saved)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]*analyzer.Statistics, len(jobs))
+	checkpoints := make([]*state.Checkpoint, len(jobs))
+	errs := make([]error, len(jobs))
+	indexes := make(chan int)
+
+	wg := sync.WaitGroup{}
+
+	for range min(workers, len(jobs)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				shards[index], checkpoints[index], errs[index] = collectJob(jobs[index], readerOptions, from, to)
+			}
+		}()
+	}
+
+	for index := range jobs {
+		indexes <- index
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if saved != nil {
+		saved.Checkpoints = make(map[string]*state.Checkpoint)
+
+		for index, checkpoint := range checkpoints {
+			if checkpoint != nil {
+				saved.Checkpoints[jobs[index].path] = checkpoint
+			}
+		}
+	}
+
+	return shards, nil
+}
+
+// collectJob собирает статистику по одной задаче в отдельную часть.
+// Для задач с сохраненной позицией чтения также возвращается новая позиция.
+func collectJob(job job, readerOptions ReaderOptions, from, to time.Time) (*analyzer.Statistics,
+	*state.Checkpoint, error) {
+	if job.checkpoint != nil {
+		return collectCheckpoint(job.path, job.checkpoint, readerOptions, from, to)
+	}
+
+	if job.chunk == nil {
+		shard, err := collectFile(job.path, readerOptions, from, to)
+
+		return shard, nil, err
+	}
+
+	shard := analyzer.NewStatistics(readerOptions.Statistics)
+
+	reader, err := file.NewChunkReader(job.path, *job.chunk, job.parser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Файл попадает в отчет один раз, вместе с первой своей частью.
+	if job.chunk.Start == 0 {
+		shard.Files = append(shard.Files, DisplayName(job.path))
+		shard.Formats[DisplayName(job.path)] = reader.Format()
+	}
+
+	err = parser.Run(reader, DisplayName(job.path), from, to, shard, readerOptions.Errors)
+
+	return shard, nil, errors.Join(err, reader.Close())
+}
+
+// collectFile собирает статистику по одному пути в отдельную часть.
+func collectFile(path string, readerOptions ReaderOptions, from, to time.Time) (*analyzer.Statistics, error) {
+	shard := analyzer.NewStatistics(readerOptions.Statistics)
+
+	open := func(path string) (input.LogReader, error) {
+		return chooseReader(path, readerOptions)
+	}
+
+	err := visitInputs(path, readerOptions, open, func(name string, reader input.LogReader) error {
+		shard.Files = append(shard.Files, name)
+		shard.Formats[name] = readerFormat(reader, readerOptions)
+
+		return parser.Run(reader, name, from, to, shard, readerOptions.Errors)
+	})
+
+	return shard, err
+}
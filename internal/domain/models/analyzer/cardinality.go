@@ -0,0 +1,46 @@
+package analyzer
+
+import "github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/sketch"
+
+// Cardinality содержит приближенное количество различных клиентов и ресурсов.
+// Оно считается по HyperLogLog, поэтому память не зависит от количества запросов, а статистику
+// разных файлов можно объединить.
+type Cardinality struct {
+	IPs       *sketch.HyperLogLog // Различные IP-адреса.
+	Clients   *sketch.HyperLogLog // Различные пары IP-адреса и User-Agent.
+	Resources *sketch.HyperLogLog // Различные ресурсы.
+
+	UniqueIPs       int // Оценка количества различных IP-адресов.
+	UniqueClients   int // Оценка количества различных пар IP-адреса и User-Agent.
+	UniqueResources int // Оценка количества различных ресурсов.
+}
+
+// NewCardinality создает пустую Cardinality.
+func NewCardinality() Cardinality {
+	return Cardinality{
+		IPs:       sketch.NewHyperLogLog(sketch.DefaultPrecision),
+		Clients:   sketch.NewHyperLogLog(sketch.DefaultPrecision),
+		Resources: sketch.NewHyperLogLog(sketch.DefaultPrecision),
+	}
+}
+
+// Add учитывает запрос с IP-адреса addr с User-Agent userAgent к ресурсу resource.
+func (c *Cardinality) Add(addr, userAgent, resource string) {
+	c.IPs.Add(addr)
+	c.Clients.AddPair(addr, userAgent)
+	c.Resources.Add(resource)
+}
+
+// Merge добавляет к Cardinality значения other.
+func (c *Cardinality) Merge(other *Cardinality) {
+	c.IPs.Merge(other.IPs)
+	c.Clients.Merge(other.Clients)
+	c.Resources.Merge(other.Resources)
+}
+
+// Estimate подсчитывает оценки количества различных IP-адресов, клиентов и ресурсов.
+func (c *Cardinality) Estimate() {
+	c.UniqueIPs = c.IPs.Estimate()
+	c.UniqueClients = c.Clients.Estimate()
+	c.UniqueResources = c.Resources.Estimate()
+}
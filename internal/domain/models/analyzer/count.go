@@ -0,0 +1,98 @@
+package analyzer
+
+import "github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/sketch"
+
+// KeyCount представляет количество запросов по строковым ключам (ресурсам или IP-адресам).
+// Если задан TopK, количества считаются приближенно с ограниченной памятью: во время сбора данных
+// учитываются только самые частые ключи, а Values заполняется их оценками в Estimate.
+type KeyCount struct {
+	Values     map[string]int // Мапа ключа и количества запросов.
+	KeysOrder  []string       // Порядок отображения ключей.
+	TopK       *sketch.TopK   `json:",omitempty"` // Самые частые ключи в приближенном режиме, nil — точный подсчет.
+	ErrorBound int            `json:",omitempty"` // Наибольшая погрешность приближенного количества.
+}
+
+// NewKeyCount создает пустой KeyCount. Если topK больше 0, количества считаются приближенно
+// и отслеживаются только topK самых частых ключей.
+func NewKeyCount(topK int) KeyCount {
+	count := KeyCount{
+		Values:    make(map[string]int),
+		KeysOrder: []string{},
+	}
+
+	if topK > 0 {
+		count.TopK = sketch.NewTopK(topK)
+	}
+
+	return count
+}
+
+// Approximate сообщает, считаются ли количества приближенно.
+func (c *KeyCount) Approximate() bool {
+	return c.TopK != nil
+}
+
+// Add учитывает один запрос с ключом key. Если в приближенном режиме ради него перестал отслеживаться
+// другой ключ, возвращает этот ключ и true.
+func (c *KeyCount) Add(key string) (evicted string, ok bool) {
+	if c.TopK != nil {
+		return c.TopK.Add(key)
+	}
+
+	c.Values[key]++
+
+	return "", false
+}
+
+// Merge добавляет к количествам запросов количества other.
+func (c *KeyCount) Merge(other *KeyCount) {
+	if c.TopK != nil {
+		c.TopK.Merge(other.TopK)
+		return
+	}
+
+	for key, cnt := range other.Values {
+		c.Values[key] += cnt
+	}
+}
+
+// Tracked сообщает, учитывается ли ключ key: в точном режиме учитываются все ключи.
+func (c *KeyCount) Tracked(key string) bool {
+	if c.TopK == nil {
+		return true
+	}
+
+	_, ok := c.TopK.Counters[key]
+
+	return ok
+}
+
+// Error возвращает, насколько приближенное количество запросов с ключом key может превышать точное.
+// В точном режиме возвращает 0.
+func (c *KeyCount) Error(key string) int {
+	if c.TopK == nil {
+		return 0
+	}
+
+	if counter, ok := c.TopK.Counters[key]; ok {
+		return counter.Error
+	}
+
+	return 0
+}
+
+// Estimate заполняет Values оценками количества запросов отслеживаемых ключей и подсчитывает ErrorBound.
+// В точном режиме ничего не делает.
+func (c *KeyCount) Estimate() {
+	if c.TopK == nil {
+		return
+	}
+
+	c.Values = make(map[string]int, len(c.TopK.Counters))
+
+	for key, counter := range c.TopK.Counters {
+		c.Values[key] = counter.Count
+	}
+
+	c.ErrorBound = c.TopK.MinCount()
+}
@@ -2,15 +2,16 @@ package analyzer
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/sketch"
 )
 
 // ResourcesCount представляет количество запросов к ресурсам.
 // Хранит значения количества запросов для каждого ресурса и порядок их отображения.
 type ResourcesCount struct {
-	Values    map[string]int // Мапа ресурса и количества запросов.
-	KeysOrder []string       // Порядок отображения ключей ресурсов.
+	KeyCount
 }
 
 // RequestsCount представляет количество запросов по коду ответа.
@@ -23,25 +24,173 @@ type RequestsCount struct {
 // IPCount представляет количество запросов по IP-адресам.
 // Хранит значения количества запросов для каждого IP и порядок их отображения.
 type IPCount struct {
+	KeyCount
+}
+
+// GroupCount представляет количество запросов по значениям произвольного поля лога (см. log.Record.Field).
+// Хранит имя поля, значения количества запросов для каждого значения поля и порядок их отображения.
+type GroupCount struct {
+	Field     string
 	Values    map[string]int
 	KeysOrder []string
 }
 
+// ParseErrors представляет строчки, которые не удалось разобрать и которые были пропущены.
+// Хранит количество строчек для каждого файла и причины ошибки.
+type ParseErrors struct {
+	Values map[string]map[string]int // Мапа файла, причины ошибки и количества строчек.
+}
+
+// Options содержит настройки собираемой статистики.
+type Options struct {
+	GroupBy   string         // Поле лога, по значениям которого группируются запросы, пустое — без группировки.
+	Location  *time.Location `json:"-"` // Часовой пояс, в котором время выводится в отчете, nil — UTC.
+	KeepQuery bool           // Учитывать ресурсы вместе со строкой запроса, а не только по пути.
+	// Относительная погрешность перцентилей размера и времени ответа, 0 — sketch.DefaultRelativeError.
+	RelativeError float64
+	// Количество самых частых ресурсов и IP-адресов, которые считаются приближенно, 0 — точный подсчет.
+	TopK int
+	// Длина интервала, по которым считается трафик во времени, 0 — не считать.
+	Bucket time.Duration
+}
+
 // Statistics содержит аналитические данные о логах запросов.
 // Дополнительно реализованными статитисками являются максимальный и минимальный размер запроса
 // А также статистика самых активных IP адресов.
 type Statistics struct {
-	Files                []string       // Список файлов, из которых были собраны данные.
-	From                 string         // Начальная дата временного диапазона.
-	To                   string         // Конечная дата временного диапазона.
-	RequestsCount        RequestsCount  // Количество запросов по коду ответа.
-	ResourcesCount       ResourcesCount // Количество запросов к ресурсам.
-	IPCount              IPCount        // Количество запросов по IP-адресам.
-	MaxSizeRequest       int            // Максимальный размер запроса.
-	MinSizeRequest       int            // Минимальный размер запроса.
-	ByteSizes            []int          // Список размеров запросов в байтах.
-	TotalRequestsNumber  *big.Int       // Общее количество запросов.
-	AverageRequestNumber *big.Int       // Среднее количество запросов.
-	ByteSize             *big.Int       // Общий размер данных в байтах.
-	Percentile           int            // Перцентиль по размеру запросов.
+	Files                []string          // Список файлов, из которых были собраны данные.
+	Formats              map[string]string // Формат лога каждого файла из Files.
+	From                 string            // Начальная дата временного диапазона.
+	To                   string            // Конечная дата временного диапазона.
+	RequestsCount        RequestsCount     // Количество запросов по коду ответа.
+	ResourcesCount       ResourcesCount    // Количество запросов к ресурсам.
+	IPCount              IPCount           // Количество запросов по IP-адресам.
+	MaxSizeRequest       int               // Максимальный размер запроса.
+	MinSizeRequest       int               // Минимальный размер запроса.
+	Sizes                *sketch.Sketch    // Распределение размеров запросов в байтах.
+	TotalRequestsNumber  *big.Int          // Общее количество запросов.
+	AverageRequestNumber *big.Int          // Среднее количество запросов.
+	ByteSize             *big.Int          // Общий размер данных в байтах.
+	Percentiles          []int             // Перцентили по размеру запросов в порядке PercentileRanks.
+	PercentileRanks      []float64         // Ранги перцентилей (например, 50, 99.9), по которым подсчитаны перцентили.
+	Latency              LatencyStats      // Время ответа запросов, если оно записано в логах.
+	Cardinality          Cardinality       // Количество различных IP-адресов, клиентов и ресурсов.
+	Traffic              Traffic           // Трафик по интервалам времени длины Options.Bucket.
+	Status               StatusStats       // Сводка по классам кодов ответа и ошибкам.
+	Methods              MethodCount       // Запросы по методам.
+	Protocols            ProtocolCount     // Количество запросов по версиям протокола.
+	GroupBy              GroupCount        // Количество запросов по значениям поля Options.GroupBy.
+	ParseErrors          ParseErrors       // Пропущенные строчки, которые не удалось разобрать.
+	LinesRead            int               // Количество прочитанных строчек, в том числе пропущенных.
+	Options              Options           // Настройки, с которыми собирается статистика.
+}
+
+// NewStatistics создает пустую статистику с настройками options, готовую к накоплению данных.
+func NewStatistics(options Options) *Statistics {
+	return &Statistics{
+		Files:   []string{},
+		Formats: make(map[string]string),
+		RequestsCount: RequestsCount{
+			Values:    make(map[log.ResponseCode]int),
+			KeysOrder: []log.ResponseCode{},
+		},
+		ResourcesCount: ResourcesCount{NewKeyCount(options.TopK)},
+		IPCount:        IPCount{NewKeyCount(options.TopK)},
+		Sizes:          sketch.New(options.RelativeError),
+		ByteSize:       big.NewInt(0),
+		GroupBy: GroupCount{
+			Field:     options.GroupBy,
+			Values:    make(map[string]int),
+			KeysOrder: []string{},
+		},
+		ParseErrors: ParseErrors{
+			Values: make(map[string]map[string]int),
+		},
+		Latency:     NewLatencyStats(options.RelativeError),
+		Cardinality: NewCardinality(),
+		Traffic:     NewTraffic(options.Bucket),
+		Status:      NewStatusStats(options.TopK),
+		Methods: MethodCount{
+			Values:    make(map[string]*Method),
+			KeysOrder: []string{},
+		},
+		Protocols: ProtocolCount{
+			Values:    make(map[string]int),
+			KeysOrder: []string{},
+		},
+		Options: options,
+	}
+}
+
+// Merge добавляет к статистике накопленные данные other (например, собранные другим воркером).
+// Итоговые значения (порядок ключей, минимальный и максимальный размер запроса, перцентиль) не объединяются,
+// их нужно подсчитать заново после слияния.
+func (s *Statistics) Merge(other *Statistics) {
+	s.Files = append(s.Files, other.Files...)
+
+	for file, format := range other.Formats {
+		s.Formats[file] = format
+	}
+
+	for code, cnt := range other.RequestsCount.Values {
+		s.RequestsCount.Values[code] += cnt
+	}
+
+	s.ResourcesCount.Merge(&other.ResourcesCount.KeyCount)
+	s.IPCount.Merge(&other.IPCount.KeyCount)
+
+	for value, cnt := range other.GroupBy.Values {
+		s.GroupBy.Values[value] += cnt
+	}
+
+	for file, reasons := range other.ParseErrors.Values {
+		for reason, cnt := range reasons {
+			s.AddParseError(file, reason, cnt)
+		}
+	}
+
+	s.LinesRead += other.LinesRead
+
+	s.Sizes.Merge(other.Sizes)
+	s.ByteSize.Add(s.ByteSize, other.ByteSize)
+
+	s.Latency.Merge(&other.Latency)
+	s.Cardinality.Merge(&other.Cardinality)
+	s.Traffic.Merge(&other.Traffic)
+	s.Status.Merge(&other.Status)
+	s.Methods.Merge(&other.Methods)
+
+	for protocol, cnt := range other.Protocols.Values {
+		s.Protocols.Values[protocol] += cnt
+	}
+
+	if s.ResourcesCount.Approximate() {
+		for resource := range s.Latency.Resources {
+			if !s.ResourcesCount.Tracked(resource) {
+				delete(s.Latency.Resources, resource)
+			}
+		}
+	}
+}
+
+// AddParseError учитывает cnt пропущенных строчек файла file с причиной ошибки reason.
+func (s *Statistics) AddParseError(file, reason string, cnt int) {
+	if s.ParseErrors.Values[file] == nil {
+		s.ParseErrors.Values[file] = make(map[string]int)
+	}
+
+	s.ParseErrors.Values[file][reason] += cnt
+}
+
+// ParseErrorsCount возвращает общее количество пропущенных строчек.
+func (s *Statistics) ParseErrorsCount() int {
+	total := 0
+
+	for _, reasons := range s.ParseErrors.Values {
+		for _, cnt := range reasons {
+			total += cnt
+		}
+	}
+
+	return total
 }
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/sketch"
+)
+
+// LatencyBuckets это верхние границы интервалов гистограммы времени ответа в секундах.
+// Запросы медленнее последней границы попадают в отдельный, последний интервал гистограммы.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Latency представляет время ответа группы запросов.
+// Хранит распределение времени ответа и итоговые значения, которые подсчитываются после слияния.
+type Latency struct {
+	Distribution *sketch.Sketch // Распределение времени ответа в секундах.
+	Min          float64        // Минимальное время ответа.
+	Max          float64        // Максимальное время ответа.
+	Mean         float64        // Среднее время ответа.
+	Percentiles  []float64      // Перцентили времени ответа в порядке Statistics.PercentileRanks.
+}
+
+// LatencyStats представляет время ответа запросов, в логах которых оно записано (см. log.Record.Latency):
+// общее, по ресурсам и по классам кодов ответа, а также гистограмму.
+type LatencyStats struct {
+	RelativeError      float64             // Относительная погрешность перцентилей времени ответа.
+	Total              Latency             // Время ответа всех запросов.
+	Resources          map[string]*Latency // Время ответа по ресурсам.
+	ResourcesOrder     []string            // Порядок отображения ресурсов.
+	StatusClasses      map[string]*Latency // Время ответа по классам кодов ответа ("2xx", "5xx").
+	StatusClassesOrder []string            // Порядок отображения классов кодов ответа.
+	Histogram          []int               // Количество запросов в интервалах LatencyBuckets.
+}
+
+// NewLatencyStats создает пустую статистику времени ответа с относительной погрешностью перцентилей relativeError.
+func NewLatencyStats(relativeError float64) LatencyStats {
+	return LatencyStats{
+		RelativeError:      relativeError,
+		Total:              Latency{Distribution: sketch.New(relativeError)},
+		Resources:          make(map[string]*Latency),
+		ResourcesOrder:     []string{},
+		StatusClasses:      make(map[string]*Latency),
+		StatusClassesOrder: []string{},
+		Histogram:          make([]int, len(LatencyBuckets)+1),
+	}
+}
+
+// Add учитывает время ответа seconds запроса с классом кода ответа class.
+func (l *LatencyStats) Add(class string, seconds float64) {
+	l.Total.Distribution.Add(seconds)
+	l.latency(l.StatusClasses, class).Distribution.Add(seconds)
+	l.Histogram[LatencyBucket(seconds)]++
+}
+
+// AddResource учитывает время ответа seconds запроса к ресурсу resource. Вызывается только для ресурсов,
+// которые учитываются в Statistics.ResourcesCount, чтобы в приближенном режиме память оставалась ограниченной.
+func (l *LatencyStats) AddResource(resource string, seconds float64) {
+	l.latency(l.Resources, resource).Distribution.Add(seconds)
+}
+
+// Merge добавляет к статистике времени ответа данные other.
+func (l *LatencyStats) Merge(other *LatencyStats) {
+	l.Total.Distribution.Merge(other.Total.Distribution)
+
+	for resource, latency := range other.Resources {
+		l.latency(l.Resources, resource).Distribution.Merge(latency.Distribution)
+	}
+
+	for class, latency := range other.StatusClasses {
+		l.latency(l.StatusClasses, class).Distribution.Merge(latency.Distribution)
+	}
+
+	for bucket, cnt := range other.Histogram {
+		l.Histogram[bucket] += cnt
+	}
+}
+
+// Count возвращает количество запросов, у которых известно время ответа.
+func (l *LatencyStats) Count() int {
+	return l.Total.Distribution.Count
+}
+
+// LatencyBucket возвращает номер интервала гистограммы для времени ответа seconds.
+func LatencyBucket(seconds float64) int {
+	for bucket, bound := range LatencyBuckets {
+		if seconds <= bound {
+			return bucket
+		}
+	}
+
+	return len(LatencyBuckets)
+}
+
+// latency возвращает время ответа группы key, создавая ее при необходимости.
+func (l *LatencyStats) latency(groups map[string]*Latency, key string) *Latency {
+	group, ok := groups[key]
+	if !ok {
+		group = &Latency{Distribution: sketch.New(l.RelativeError)}
+		groups[key] = group
+	}
+
+	return group
+}
@@ -0,0 +1,91 @@
+package analyzer
+
+// Method представляет запросы одним методом.
+type Method struct {
+	Requests int  // Количество запросов.
+	Bytes    int  // Размер ответов в байтах.
+	Errors   int  // Количество запросов с кодом ответа 4xx или 5xx.
+	Unusual  bool // Метод не используется обычными клиентами (см. log.IsUnusualMethod).
+}
+
+// ErrorRate возвращает долю запросов с ошибками в процентах.
+func (m *Method) ErrorRate() float64 {
+	if m.Requests == 0 {
+		return 0
+	}
+
+	return float64(m.Errors) * 100 / float64(m.Requests)
+}
+
+// MethodCount представляет запросы по методам.
+// Хранит количество запросов, размер ответов и количество ошибок каждого метода и порядок их отображения.
+type MethodCount struct {
+	Values    map[string]*Method // Мапа метода и запросов им.
+	KeysOrder []string           // Порядок отображения методов.
+}
+
+// ProtocolCount представляет количество запросов по версиям протокола ("HTTP/1.1", "HTTP/2.0").
+// Хранит значения количества запросов для каждого протокола и порядок их отображения.
+type ProtocolCount struct {
+	Values    map[string]int
+	KeysOrder []string
+}
+
+// Add учитывает запрос методом method размером bytes. failed означает, что запрос завершился ошибкой,
+// а unusual — что метод не используется обычными клиентами.
+func (c *MethodCount) Add(method string, bytes int, failed, unusual bool) {
+	stats := c.method(method, unusual)
+	stats.Requests++
+	stats.Bytes += bytes
+
+	if failed {
+		stats.Errors++
+	}
+}
+
+// Merge добавляет к запросам по методам данные other.
+func (c *MethodCount) Merge(other *MethodCount) {
+	for method, otherStats := range other.Values {
+		stats := c.method(method, otherStats.Unusual)
+		stats.Requests += otherStats.Requests
+		stats.Bytes += otherStats.Bytes
+		stats.Errors += otherStats.Errors
+	}
+}
+
+// Requests возвращает количество запросов каждым методом.
+func (c *MethodCount) Requests() map[string]int {
+	requests := make(map[string]int, len(c.Values))
+
+	for method, stats := range c.Values {
+		requests[method] = stats.Requests
+	}
+
+	return requests
+}
+
+// Unusual возвращает необычные методы в порядке отображения и общее количество запросов ими.
+func (c *MethodCount) Unusual() (methods []string, requests int) {
+	for _, method := range c.KeysOrder {
+		if stats := c.Values[method]; stats.Unusual {
+			methods = append(methods, method)
+			requests += stats.Requests
+		}
+	}
+
+	return methods, requests
+}
+
+func (c *MethodCount) method(method string, unusual bool) *Method {
+	if c.Values == nil {
+		c.Values = make(map[string]*Method)
+	}
+
+	stats, ok := c.Values[method]
+	if !ok {
+		stats = &Method{Unusual: unusual}
+		c.Values[method] = stats
+	}
+
+	return stats
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+// StatusStats представляет сводку по классам кодов ответа и ошибкам.
+// Количество запросов с ошибками по ресурсам накапливается при сборе данных, а классы кодов ответа
+// и доли ошибок подсчитываются после слияния по Statistics.RequestsCount.
+type StatusStats struct {
+	Classes         map[string]int // Количество запросов по классам кодов ответа ("2xx", "5xx").
+	ClassesOrder    []string       // Порядок отображения классов кодов ответа.
+	ErrorRate       float64        // Доля запросов с кодом ответа 4xx или 5xx в процентах.
+	ClientErrorRate float64        // Доля запросов с кодом ответа 4xx в процентах.
+	ServerErrorRate float64        // Доля запросов с кодом ответа 5xx в процентах.
+	ClientErrors    KeyCount       // Количество запросов с кодом ответа 4xx по ресурсам.
+	ServerErrors    KeyCount       // Количество запросов с кодом ответа 5xx по ресурсам.
+}
+
+// NewStatusStats создает пустую StatusStats. Если topK больше 0, ресурсы с ошибками считаются приближенно
+// (см. KeyCount).
+func NewStatusStats(topK int) StatusStats {
+	return StatusStats{
+		Classes:      make(map[string]int),
+		ClassesOrder: []string{},
+		ClientErrors: NewKeyCount(topK),
+		ServerErrors: NewKeyCount(topK),
+	}
+}
+
+// Add учитывает запрос к ресурсу resource с классом кода ответа class.
+func (s *StatusStats) Add(resource, class string) {
+	switch class {
+	case "4xx":
+		s.ClientErrors.Add(resource)
+	case "5xx":
+		s.ServerErrors.Add(resource)
+	}
+}
+
+// Merge добавляет к сводке данные other.
+func (s *StatusStats) Merge(other *StatusStats) {
+	s.ClientErrors.Merge(&other.ClientErrors)
+	s.ServerErrors.Merge(&other.ServerErrors)
+}
@@ -0,0 +1,111 @@
+package analyzer
+
+import "time"
+
+// MaxTrafficBuckets это наибольшее количество интервалов, на которое делится окно --from/--to.
+// Если окно длиннее (например, --from и --to по умолчанию охватывают полтора века), ряд строится
+// только между первым и последним интервалом, в которые попали запросы.
+const MaxTrafficBuckets = 10_000
+
+const day = 24 * time.Hour
+
+// TrafficBucket представляет трафик за один интервал времени.
+type TrafficBucket struct {
+	Start    time.Time // Начало интервала.
+	Requests int       // Количество запросов.
+	Bytes    int       // Размер ответов в байтах.
+	Errors   int       // Количество запросов с кодом ответа 4xx или 5xx.
+}
+
+// Traffic представляет трафик по интервалам времени одинаковой длины.
+// Хранит трафик непустых интервалов и итоговый ряд всех интервалов окна, который подсчитывается после слияния.
+type Traffic struct {
+	Bucket   time.Duration            // Длина интервала, 0 — трафик по времени не собирается.
+	Buckets  map[int64]*TrafficBucket // Трафик непустых интервалов по началу интервала в секундах с начала эпохи.
+	From     time.Time                `json:"-"` // Начало окна, в котором строится ряд.
+	To       time.Time                `json:"-"` // Конец окна, в котором строится ряд.
+	Series   []TrafficBucket          // Все интервалы окна по порядку, в том числе без запросов.
+	Peak     int                      // Номер интервала в Series с наибольшим количеством запросов.
+	Quietest int                      // Номер интервала в Series с наименьшим количеством запросов.
+}
+
+// NewTraffic создает пустой Traffic с интервалами длины bucket.
+func NewTraffic(bucket time.Duration) Traffic {
+	return Traffic{
+		Bucket:  bucket,
+		Buckets: make(map[int64]*TrafficBucket),
+		Series:  []TrafficBucket{},
+	}
+}
+
+// Add учитывает запрос в момент date размером bytes. failed означает, что запрос завершился ошибкой.
+// Интервалы выравниваются по времени в часовом поясе location.
+func (t *Traffic) Add(date time.Time, location *time.Location, bytes int, failed bool) {
+	if t.Bucket <= 0 {
+		return
+	}
+
+	bucket := t.bucket(BucketStart(date, t.Bucket, location))
+	bucket.Requests++
+	bucket.Bytes += bytes
+
+	if failed {
+		bucket.Errors++
+	}
+}
+
+// Merge добавляет к трафику данные other.
+func (t *Traffic) Merge(other *Traffic) {
+	for _, otherBucket := range other.Buckets {
+		bucket := t.bucket(otherBucket.Start)
+		bucket.Requests += otherBucket.Requests
+		bucket.Bytes += otherBucket.Bytes
+		bucket.Errors += otherBucket.Errors
+	}
+}
+
+func (t *Traffic) bucket(start time.Time) *TrafficBucket {
+	if t.Buckets == nil {
+		t.Buckets = make(map[int64]*TrafficBucket)
+	}
+
+	bucket, ok := t.Buckets[start.Unix()]
+	if !ok {
+		bucket = &TrafficBucket{Start: start}
+		t.Buckets[start.Unix()] = bucket
+	}
+
+	return bucket
+}
+
+// BucketStart возвращает начало интервала длины bucket, в который попадает момент date.
+// Интервалы выравниваются по времени в часовом поясе location (nil — UTC): сутки начинаются в полночь,
+// а часы и минуты отсчитываются от ее начала.
+func BucketStart(date time.Time, bucket time.Duration, location *time.Location) time.Time {
+	if location == nil {
+		location = time.UTC
+	}
+
+	local := date.In(location)
+
+	if bucket == day {
+		year, month, dayOfMonth := local.Date()
+
+		return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, location)
+	}
+
+	_, offset := local.Zone()
+	seconds := local.Unix() + int64(offset)
+	length := int64(bucket / time.Second)
+
+	return time.Unix(seconds-(seconds%length+length)%length-int64(offset), 0).In(location)
+}
+
+// NextBucket возвращает начало интервала длины bucket, следующего за интервалом, который начинается в start.
+func NextBucket(start time.Time, bucket time.Duration) time.Time {
+	if bucket == day {
+		return start.AddDate(0, 0, 1)
+	}
+
+	return start.Add(bucket)
+}
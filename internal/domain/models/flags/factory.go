@@ -15,71 +15,190 @@ const (
 	Directory
 	Filename
 	Percentile
+	ConnectTimeout
+	ReadTimeout
+	Retries
+	Follow
+	Refresh
+	Exclude
+	ArchiveGlob
+	Workers
+	StateFile
+	LogFormat
+	GroupBy
+	JSONField
+	Timezone
+	OnError
+	QuarantineFile
+	KeepQuery
+	RelativeError
+	TopK
+	Bucket
 	FlagCount
 
 	StringFlag
 	IntegerFlag
+	BoolFlag
+	StringSliceFlag
+	FloatFlag
+	FloatSliceFlag
+	StringArrayFlag
 )
 
 var (
 	FlagToName = map[FlagIota]string{
-		Path:        "path",
-		From:        "from",
-		To:          "to",
-		Format:      "format",
-		FilterField: "filter-field",
-		FilterValue: "filter-value",
-		Directory:   "directory",
-		Filename:    "filename",
-		Percentile:  "percentile",
+		Path:           "path",
+		From:           "from",
+		To:             "to",
+		Format:         "format",
+		FilterField:    "filter-field",
+		FilterValue:    "filter-value",
+		Directory:      "directory",
+		Filename:       "filename",
+		Percentile:     "percentile",
+		ConnectTimeout: "connect-timeout",
+		ReadTimeout:    "read-timeout",
+		Retries:        "retries",
+		Follow:         "follow",
+		Refresh:        "refresh",
+		Exclude:        "exclude",
+		ArchiveGlob:    "archive-glob",
+		Workers:        "workers",
+		StateFile:      "state-file",
+		LogFormat:      "log-format",
+		GroupBy:        "group-by",
+		JSONField:      "json-field",
+		Timezone:       "timezone",
+		OnError:        "on-error",
+		QuarantineFile: "quarantine-file",
+		KeepQuery:      "keep-query",
+		RelativeError:  "relative-error",
+		TopK:           "top-k",
+		Bucket:         "bucket",
 	}
 
 	FlagToShorthandName = map[FlagIota]string{
-		Path:        "p",
-		From:        "f",
-		To:          "t",
-		Format:      "m",
-		FilterField: "i",
-		FilterValue: "a",
-		Directory:   "d",
-		Filename:    "n",
-		Percentile:  "c",
+		Path:           "p",
+		From:           "f",
+		To:             "t",
+		Format:         "m",
+		FilterField:    "i",
+		FilterValue:    "a",
+		Directory:      "d",
+		Filename:       "n",
+		Percentile:     "c",
+		ConnectTimeout: "",
+		ReadTimeout:    "",
+		Retries:        "",
+		Follow:         "",
+		Refresh:        "",
+		Exclude:        "e",
+		ArchiveGlob:    "",
+		Workers:        "w",
+		StateFile:      "",
+		LogFormat:      "",
+		GroupBy:        "",
+		JSONField:      "",
+		Timezone:       "",
+		OnError:        "",
+		QuarantineFile: "",
+		KeepQuery:      "",
+		RelativeError:  "",
+		TopK:           "",
+		Bucket:         "",
 	}
 
 	FlagToUsage = map[FlagIota]string{
-		Path:        "Set a path to processing file",
-		From:        "Filters out logs that have a date later than the specified one",
-		To:          "Filters out logs that have a date before than the specified one",
-		Format:      "Sets an output data visual",
-		FilterField: "Sets the field that would be used to filter logs",
-		FilterValue: "Sets the value that would be used to filter logs (Use only with \"filter-field\")",
-		Directory:   "Sets the directory where statistics will be saved",
-		Filename:    "Sets the statistics output file",
-		Percentile:  "Sets the percentile",
+		Path:           "Set a path to processing file (can be repeated)",
+		From:           "Filters out logs that have a date later than the specified one",
+		To:             "Filters out logs that have a date before than the specified one",
+		Format:         "Sets an output data visual",
+		FilterField:    "Sets the field that would be used to filter logs",
+		FilterValue:    "Sets the value that would be used to filter logs (Use only with \"filter-field\")",
+		Directory:      "Sets the directory where statistics will be saved",
+		Filename:       "Sets the statistics output file",
+		Percentile:     "Sets the percentiles from 0 to 100, fractional ones included, e.g. 50,90,99.9 (can be repeated)",
+		ConnectTimeout: "Sets the timeout in seconds for connecting to a remote log",
+		ReadTimeout:    "Sets the timeout in seconds for waiting data from a remote log",
+		Retries:        "Sets the number of attempts to resume reading a remote log after a failure",
+		Follow:         "Keeps reading files as they grow and periodically rewrites statistics",
+		Refresh:        "Sets the interval in seconds between statistics rewrites (Use only with \"follow\")",
+		Exclude:        "Excludes files matching the pattern from processing (can be repeated)",
+		ArchiveGlob:    "Processes only files inside tar and zip archives that match the pattern",
+		Workers:        "Sets the number of files processed in parallel (0 means the number of CPUs)",
+		StateFile:      "Sets the file where read positions are kept between runs to process only new lines",
+		LogFormat:      "Sets the log format: a preset (combined, common, vhost_combined, json, auto), an nginx log_format or an Apache LogFormat string",
+		GroupBy:        "Sets the log field (or log_format variable) whose values requests are grouped by",
+		JSONField:      "Maps a log field to a JSON key for the \"json\" log format, e.g. date=ts or addr=client.ip (can be repeated)",
+		Timezone:       "Sets the timezone (IANA name or offset like +0300) for report times and dates in \"from\" and \"to\"",
+		OnError:        "Sets what to do with lines that cannot be parsed: fail, skip or quarantine",
+		QuarantineFile: "Sets the file where rejected lines are written (Use only with \"on-error=quarantine\")",
+		KeepQuery:      "Keeps the query string in resource names instead of counting requests by path",
+		RelativeError:  "Sets the relative error of size and latency percentiles, e.g. 0.01 for 1%",
+		TopK:           "Counts resources and IPs approximately in bounded memory, keeping only the K most frequent ones (0 means exact counts)",
+		Bucket:         "Breaks traffic down over time into buckets of 1m, 5m, 1h or 1d",
 	}
 
 	FlagToValueType = map[FlagIota]FlagType{
-		Path:        StringFlag,
-		From:        StringFlag,
-		To:          StringFlag,
-		Format:      StringFlag,
-		FilterField: StringFlag,
-		FilterValue: StringFlag,
-		Directory:   StringFlag,
-		Filename:    StringFlag,
-		Percentile:  IntegerFlag,
+		Path:           StringArrayFlag,
+		From:           StringFlag,
+		To:             StringFlag,
+		Format:         StringFlag,
+		FilterField:    StringFlag,
+		FilterValue:    StringFlag,
+		Directory:      StringFlag,
+		Filename:       StringFlag,
+		Percentile:     FloatSliceFlag,
+		ConnectTimeout: IntegerFlag,
+		ReadTimeout:    IntegerFlag,
+		Retries:        IntegerFlag,
+		Follow:         BoolFlag,
+		Refresh:        IntegerFlag,
+		Exclude:        StringArrayFlag,
+		ArchiveGlob:    StringFlag,
+		Workers:        IntegerFlag,
+		StateFile:      StringFlag,
+		LogFormat:      StringFlag,
+		GroupBy:        StringFlag,
+		JSONField:      StringSliceFlag,
+		Timezone:       StringFlag,
+		OnError:        StringFlag,
+		QuarantineFile: StringFlag,
+		KeepQuery:      BoolFlag,
+		RelativeError:  FloatFlag,
+		TopK:           IntegerFlag,
+		Bucket:         StringFlag,
 	}
 
 	FlagToDefaultValue = map[FlagIota]interface{}{
-		Path:        "/*",
-		From:        "1900-01-01",
-		To:          "2050-01-31",
-		Format:      "markdown",
-		FilterField: "",
-		FilterValue: "",
-		Directory:   "",
-		Filename:    "statistics",
-		Percentile:  95,
+		Path:           []string{"/*"},
+		From:           "1900-01-01",
+		To:             "2050-01-31",
+		Format:         "markdown",
+		FilterField:    "",
+		FilterValue:    "",
+		Directory:      "",
+		Filename:       "statistics",
+		Percentile:     []float64{95},
+		ConnectTimeout: 10,
+		ReadTimeout:    30,
+		Retries:        3,
+		Follow:         false,
+		Refresh:        10,
+		Exclude:        []string{},
+		ArchiveGlob:    "",
+		Workers:        1,
+		StateFile:      "",
+		LogFormat:      "combined",
+		GroupBy:        "",
+		JSONField:      []string{},
+		Timezone:       "UTC",
+		OnError:        "fail",
+		QuarantineFile: "quarantine.log",
+		KeepQuery:      false,
+		RelativeError:  0.01,
+		TopK:           0,
+		Bucket:         "",
 	}
 
 	ErrTypeNotProvided = errors.New("type not provided")
@@ -95,6 +214,16 @@ func CreateValue(valueType, flagType int) (Value, error) {
 		return NewStringValue(FlagToDefaultValue[flagType].(string)), nil
 	case IntegerFlag:
 		return NewIntegerValue(FlagToDefaultValue[flagType].(int)), nil
+	case BoolFlag:
+		return NewBoolValue(FlagToDefaultValue[flagType].(bool)), nil
+	case StringSliceFlag:
+		return NewStringSliceValue(FlagToDefaultValue[flagType].([]string)), nil
+	case FloatFlag:
+		return NewFloatValue(FlagToDefaultValue[flagType].(float64)), nil
+	case FloatSliceFlag:
+		return NewFloatSliceValue(FlagToDefaultValue[flagType].([]float64)), nil
+	case StringArrayFlag:
+		return NewStringArrayValue(FlagToDefaultValue[flagType].([]string)), nil
 	default:
 		return nil, ErrTypeNotProvided
 	}
@@ -34,6 +34,51 @@ func (f *Flag) GetInt() (int, error) {
 	}
 }
 
+func (f *Flag) GetBool() (bool, error) {
+	switch val := f.Value.(type) {
+	case *BoolValue:
+		return val.Value(), nil
+	default:
+		return false, ErrCannotGetValue
+	}
+}
+
+func (f *Flag) GetFloat() (float64, error) {
+	switch val := f.Value.(type) {
+	case *FloatValue:
+		return val.Value(), nil
+	default:
+		return -1, ErrCannotGetValue
+	}
+}
+
+func (f *Flag) GetFloatSlice() ([]float64, error) {
+	switch val := f.Value.(type) {
+	case *FloatSliceValue:
+		return val.Value(), nil
+	default:
+		return nil, ErrCannotGetValue
+	}
+}
+
+func (f *Flag) GetStringSlice() ([]string, error) {
+	switch val := f.Value.(type) {
+	case *StringSliceValue:
+		return val.Value(), nil
+	default:
+		return nil, ErrCannotGetValue
+	}
+}
+
+func (f *Flag) GetStringArray() ([]string, error) {
+	switch val := f.Value.(type) {
+	case *StringArrayValue:
+		return val.Value(), nil
+	default:
+		return nil, ErrCannotGetValue
+	}
+}
+
 type Value interface {
 	Type() string
 }
@@ -91,3 +136,140 @@ func (iv *IntegerValue) Value() int {
 func (iv *IntegerValue) DefaultValue() int {
 	return iv.defaultValue
 }
+
+type BoolValue struct {
+	value        bool
+	defaultValue bool
+}
+
+func NewBoolValue(defaultValue bool) *BoolValue {
+	s := BoolValue{
+		defaultValue: defaultValue,
+	}
+
+	return &s
+}
+
+func (bv *BoolValue) Type() string { return "bool" }
+
+func (bv *BoolValue) Pointer() *bool {
+	return &bv.value
+}
+
+func (bv *BoolValue) Value() bool {
+	return bv.value
+}
+
+func (bv *BoolValue) DefaultValue() bool {
+	return bv.defaultValue
+}
+
+type StringSliceValue struct {
+	value        []string
+	defaultValue []string
+}
+
+func NewStringSliceValue(defaultValue []string) *StringSliceValue {
+	s := StringSliceValue{
+		defaultValue: defaultValue,
+	}
+
+	return &s
+}
+
+func (ssv *StringSliceValue) Type() string { return "stringSlice" }
+
+func (ssv *StringSliceValue) Pointer() *[]string {
+	return &ssv.value
+}
+
+func (ssv *StringSliceValue) Value() []string {
+	return ssv.value
+}
+
+func (ssv *StringSliceValue) DefaultValue() []string {
+	return ssv.defaultValue
+}
+
+type FloatValue struct {
+	value        float64
+	defaultValue float64
+}
+
+func NewFloatValue(defaultValue float64) *FloatValue {
+	s := FloatValue{
+		defaultValue: defaultValue,
+	}
+
+	return &s
+}
+
+func (fv *FloatValue) Type() string { return "float64" }
+
+func (fv *FloatValue) Pointer() *float64 {
+	return &fv.value
+}
+
+func (fv *FloatValue) Value() float64 {
+	return fv.value
+}
+
+func (fv *FloatValue) DefaultValue() float64 {
+	return fv.defaultValue
+}
+
+type FloatSliceValue struct {
+	value        []float64
+	defaultValue []float64
+}
+
+func NewFloatSliceValue(defaultValue []float64) *FloatSliceValue {
+	s := FloatSliceValue{
+		defaultValue: defaultValue,
+	}
+
+	return &s
+}
+
+func (fsv *FloatSliceValue) Type() string { return "float64Slice" }
+
+func (fsv *FloatSliceValue) Pointer() *[]float64 {
+	return &fsv.value
+}
+
+func (fsv *FloatSliceValue) Value() []float64 {
+	return fsv.value
+}
+
+func (fsv *FloatSliceValue) DefaultValue() []float64 {
+	return fsv.defaultValue
+}
+
+// StringArrayValue это значение флага, который можно повторять. В отличие от StringSliceValue,
+// значение не делится по запятым, поэтому подходит для путей, в именах которых есть запятые.
+type StringArrayValue struct {
+	value        []string
+	defaultValue []string
+}
+
+func NewStringArrayValue(defaultValue []string) *StringArrayValue {
+	s := StringArrayValue{
+		defaultValue: defaultValue,
+	}
+
+	return &s
+}
+
+func (sav *StringArrayValue) Type() string { return "stringArray" }
+
+func (sav *StringArrayValue) Pointer() *[]string {
+	return &sav.value
+}
+
+func (sav *StringArrayValue) Value() []string {
+	return sav.value
+}
+
+func (sav *StringArrayValue) DefaultValue() []string {
+	return sav.defaultValue
+}
@@ -2,23 +2,35 @@ package iso
 
 import (
 	"errors"
+	"regexp"
+	"strconv"
 	"time"
 )
 
 const (
 	isoSimpleLayout = "2006-01-02"
+	offsetPattern   = `^([+-])(0[0-9]|1[0-4]):?([0-5][0-9])$`
 )
 
 var (
+	offsetRegexp = regexp.MustCompile(offsetPattern)
+
 	ErrInvalidISOFormat = errors.New("invalid ISO format")
+	ErrInvalidTimezone  = errors.New("invalid timezone")
 )
 
 // ParseTime принимает строчку и преобразовывает её в time.Time
 // Возвращает ErrInvalidISOFormat если преобразовать невозможно.
 func ParseTime(timeString string) (time.Time, error) {
+	return ParseTimeIn(timeString, time.UTC)
+}
+
+// ParseTimeIn работает так же, как ParseTime, но дата без времени ("2006-01-02")
+// означает начало суток в часовом поясе location. У времени в формате RFC3339 свое смещение.
+func ParseTimeIn(timeString string, location *time.Location) (time.Time, error) {
 	isoWithTime, err := time.Parse(time.RFC3339, timeString)
 	if err != nil {
-		isoSimple, err1 := time.Parse(isoSimpleLayout, timeString)
+		isoSimple, err1 := time.ParseInLocation(isoSimpleLayout, timeString, location)
 
 		if err1 != nil {
 			return time.Time{}, ErrInvalidISOFormat
@@ -29,3 +41,38 @@ func ParseTime(timeString string) (time.Time, error) {
 
 	return isoWithTime, nil
 }
+
+// IsDate проверяет, является ли timeString датой без времени ("2006-01-02").
+func IsDate(timeString string) bool {
+	_, err := time.Parse(isoSimpleLayout, timeString)
+
+	return err == nil
+}
+
+// ParseLocation возвращает часовой пояс по имени из базы IANA ("Europe/Moscow", "UTC", "Local")
+// или по смещению от UTC ("+0300", "-07:00").
+// Возвращает ErrInvalidTimezone, если часовой пояс не найден.
+func ParseLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	if matches := offsetRegexp.FindStringSubmatch(name); matches != nil {
+		hours, _ := strconv.Atoi(matches[2])
+		minutes, _ := strconv.Atoi(matches[3])
+
+		offset := hours*3600 + minutes*60
+		if matches[1] == "-" {
+			offset = -offset
+		}
+
+		return time.FixedZone(name, offset), nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	return location, nil
+}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ApacheCommonFormat это директива LogFormat встроенного формата Apache "common".
+	ApacheCommonFormat = `%h %l %u %t "%r" %>s %b`
+	// ApacheCombinedFormat это директива LogFormat встроенного формата Apache "combined".
+	ApacheCombinedFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"`
+	// ApacheVhostCombinedFormat это директива LogFormat встроенного формата Apache "vhost_combined".
+	ApacheVhostCombinedFormat = `%v:%p %h %l %u %t "%r" %>s %O "%{Referer}i" "%{User-Agent}i"`
+
+	apacheDirectivePattern = `%(?:!?[0-9,]+)?[<>]?(?:\{([^}]*)\})?([a-zA-Z%])`
+)
+
+var (
+	apacheDirectiveRegexp = regexp.MustCompile(apacheDirectivePattern)
+
+	// apacheDirectiveToNginx сопоставляет директивам Apache LogFormat без параметра переменные nginx.
+	apacheDirectiveToNginx = map[string]string{
+		"a": "remote_addr",
+		"A": "server_addr",
+		"B": "body_bytes_sent",
+		"b": "body_bytes_sent",
+		"D": "request_time_us",
+		"f": "request_filename",
+		"h": "remote_addr",
+		"H": "server_protocol",
+		"I": "request_length",
+		"k": "keepalive_requests",
+		"l": "ident",
+		"L": "log_id",
+		"m": "request_method",
+		"O": "bytes_sent",
+		"p": "server_port",
+		"P": "pid",
+		"q": "query_string",
+		"r": "request",
+		"R": "handler",
+		"s": "status",
+		"S": "bytes_transferred",
+		"t": "time_local",
+		"T": "request_time",
+		"u": "remote_user",
+		"U": "request_uri",
+		"v": "server_name",
+		"V": "host",
+		"X": "connection_status",
+	}
+
+	// apacheParameterPrefix сопоставляет директивам Apache вида %{name}x префиксы переменных nginx.
+	apacheParameterPrefix = map[string]string{
+		"i": "http_",
+		"o": "sent_http_",
+		"C": "cookie_",
+		"e": "env_",
+		"n": "note_",
+	}
+
+	apacheDurationUnits = map[string]string{
+		"s":  "request_time",
+		"ms": "request_time_ms",
+		"us": "request_time_us",
+	}
+
+	apachePortTypes = map[string]string{
+		"canonical": "server_port",
+		"local":     "server_port",
+		"remote":    "remote_port",
+	}
+
+	nonVariableSymbols = regexp.MustCompile(`[^a-z0-9_]`)
+
+	ErrUnsupportedDirective = errors.New("unsupported log format directive")
+)
+
+// CompileApache компилирует строку директивы Apache httpd LogFormat, например `%h %l %u %t "%r" %>s %b %D`.
+// Директивы переводятся в эквивалентные переменные nginx, поэтому результат работает так же, как CompileNginx:
+// %h, %t, %r, %>s и %b заполняют поля Record, а остальные директивы попадают в Record.Fields.
+// Имена дополнительных полей следуют nginx: %D это "request_time_us", %T — "request_time",
+// %v — "server_name", %{X-Forwarded-For}i — "http_x_forwarded_for".
+// Текст между директивами, в том числе "$", совпадает сам с собой и не считается переменной nginx.
+func CompileApache(format string) (*NginxParser, error) {
+	format = strings.TrimSpace(format)
+	hasHost := strings.Contains(format, "%h")
+
+	translated := strings.Builder{}
+	last := 0
+
+	// locations это позиции переменных nginx в translated, см. compileNginx.
+	var locations [][]int
+
+	for _, location := range apacheDirectiveRegexp.FindAllStringSubmatchIndex(format, -1) {
+		translated.WriteString(format[last:location[0]])
+		last = location[1]
+
+		parameter := submatch(format, location, 1)
+		directive := submatch(format, location, 2)
+
+		if directive == "%" {
+			translated.WriteString("%")
+			continue
+		}
+
+		variable, err := apacheVariable(directive, parameter, hasHost)
+		if err != nil {
+			return nil, err
+		}
+
+		if directive == "t" && parameter == "" {
+			translated.WriteString("[")
+		}
+
+		start := translated.Len()
+		translated.WriteString("${" + variable + "}")
+		locations = append(locations, []int{start, translated.Len(), start + 2, translated.Len() - 1, -1, -1})
+
+		if directive == "t" && parameter == "" {
+			translated.WriteString("]")
+		}
+	}
+
+	translated.WriteString(format[last:])
+
+	parser, err := compileNginx(translated.String(), locations)
+	if errors.Is(err, ErrIncompleteFormat) {
+		return nil, fmt.Errorf("%w: %%h (or %%a), %%t, %%r (or %%m and %%U), %%>s and %%b (or %%B, %%O) are required",
+			ErrIncompleteFormat)
+	}
+
+	return parser, err
+}
+
+// apacheVariable возвращает имя переменной nginx для директивы Apache directive с параметром parameter.
+func apacheVariable(directive, parameter string, hasHost bool) (string, error) {
+	unsupported := fmt.Errorf("%w: %%{%s}%s", ErrUnsupportedDirective, parameter, directive)
+
+	if prefix, ok := apacheParameterPrefix[directive]; ok {
+		if parameter == "" {
+			return "", unsupported
+		}
+
+		return prefix + nonVariableSymbols.ReplaceAllString(strings.ToLower(parameter), "_"), nil
+	}
+
+	if parameter == "" {
+		variable, ok := apacheDirectiveToNginx[directive]
+		if !ok {
+			return "", fmt.Errorf("%w: %%%s", ErrUnsupportedDirective, directive)
+		}
+
+		// Если в формате есть и %h, и %a, адресом клиента считается %h.
+		if directive == "a" && hasHost {
+			return "client_addr", nil
+		}
+
+		return variable, nil
+	}
+
+	switch directive {
+	case "t":
+		// Время в секундах с начала эпохи разбирается так же, как $msec в nginx.
+		if parameter == "sec" {
+			return "msec", nil
+		}
+	case "T":
+		if variable, ok := apacheDurationUnits[parameter]; ok {
+			return variable, nil
+		}
+	case "p":
+		if variable, ok := apachePortTypes[parameter]; ok {
+			return variable, nil
+		}
+	}
+
+	return "", unsupported
+}
@@ -0,0 +1,251 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CombinedTokens это части строчки в формате "combined" до их проверки и преобразования.
+// Части ссылаются на саму строчку, поэтому токенизация не выделяет память.
+type CombinedTokens struct {
+	Addr      string
+	User      string
+	Date      string
+	Request   string
+	Status    string
+	Bytes     string
+	Referer   string
+	UserAgent string
+}
+
+// TokenizeCombined разбирает строчку в формате "combined" за один проход по байтам, без регулярных выражений.
+// Возвращает false, если строчка не подходит под формат "combined".
+func TokenizeCombined(line string, tokens *CombinedTokens) bool {
+	i := 0
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+
+	var ok bool
+
+	if tokens.Addr, i = nonSpace(line, i); tokens.Addr == "" {
+		return false
+	}
+
+	if i, ok = literal(line, i, " - "); !ok {
+		return false
+	}
+
+	if tokens.User, i = nonSpace(line, i); tokens.User == "" {
+		return false
+	}
+
+	if i, ok = literal(line, i, " ["); !ok {
+		return false
+	}
+
+	if tokens.Date, i = upTo(line, i, ']'); tokens.Date == "" {
+		return false
+	}
+
+	if i, ok = literal(line, i, `] "`); !ok {
+		return false
+	}
+
+	if tokens.Request, i, ok = request(line, i); !ok {
+		return false
+	}
+
+	if i, ok = literal(line, i, `" `); !ok {
+		return false
+	}
+
+	if tokens.Status, i = digits(line, i); len(tokens.Status) != 3 {
+		return false
+	}
+
+	if i, ok = literal(line, i, " "); !ok {
+		return false
+	}
+
+	if i, ok = literal(line, i, "-"); ok {
+		tokens.Bytes = "-"
+	} else if tokens.Bytes, i = digits(line, i); tokens.Bytes == "" {
+		return false
+	}
+
+	if i, ok = literal(line, i, ` "`); !ok {
+		return false
+	}
+
+	tokens.Referer, i = upTo(line, i, '"')
+
+	if i, ok = literal(line, i, `" "`); !ok {
+		return false
+	}
+
+	tokens.UserAgent, i = upTo(line, i, '"')
+
+	_, ok = literal(line, i, `"`)
+
+	return ok
+}
+
+// request выделяет "$request" до закрывающей кавычки. Кавычки внутри "$request" должны быть
+// экранированы обратной косой чертой: nginx записывает их как "\x22", Apache — как "\"".
+func request(line string, start int) (string, int, bool) {
+	i := start
+
+	for i < len(line) && line[i] != '"' {
+		if line[i] == '\\' {
+			// Как и "." в регулярном выражении, экранировать можно любой символ, кроме перевода строки.
+			if i+1 == len(line) || line[i+1] == '\n' {
+				return "", start, false
+			}
+
+			i++
+		}
+
+		i++
+	}
+
+	return line[start:i], i, true
+}
+
+// nonSpace возвращает часть строчки без пробельных символов, начинающуюся с позиции i, и позицию после нее.
+func nonSpace(line string, i int) (string, int) {
+	end := i
+	for end < len(line) && !isSpace(line[end]) {
+		end++
+	}
+
+	return line[i:end], end
+}
+
+// digits возвращает часть строчки из десятичных цифр, начинающуюся с позиции i, и позицию после нее.
+func digits(line string, i int) (string, int) {
+	end := i
+	for end < len(line) && isDigit(line[end]) {
+		end++
+	}
+
+	return line[i:end], end
+}
+
+// upTo возвращает часть строчки от позиции i до символа c (или до конца строчки) и позицию этого символа.
+func upTo(line string, i int, c byte) (string, int) {
+	end := strings.IndexByte(line[i:], c)
+	if end == -1 {
+		return line[i:], len(line)
+	}
+
+	return line[i : i+end], i + end
+}
+
+// literal проверяет, что с позиции i в строчке записан text, и возвращает позицию после него.
+func literal(line string, i int, text string) (int, bool) {
+	if !strings.HasPrefix(line[i:], text) {
+		return i, false
+	}
+
+	return i + len(text), true
+}
+
+// isSpace совпадает с классом \s регулярных выражений Go.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// Field возвращает часть строчки по имени поля, как и Record.Field, но без преобразований,
+// поэтому фильтрация по --filter-field не зависит от способа разбора строчки.
+// Возвращает false, если такого поля нет.
+func (t *CombinedTokens) Field(name string) (string, bool) {
+	switch name {
+	case "addr":
+		return t.Addr, true
+	case "user":
+		return t.User, true
+	case "date":
+		return t.Date, true
+	case "request":
+		return t.Request, true
+	case "status":
+		return t.Status, true
+	case "bytes":
+		return t.Bytes, true
+	case "referer":
+		return t.Referer, true
+	case "user_agent":
+		return t.UserAgent, true
+	}
+
+	return "", false
+}
+
+// Parse проверяет части строчки и записывает их в record.
+// Возвращает ошибку, если адрес, статус или размер ответа некорректны. Некорректная дата ошибкой
+// не считается: record получает нулевую дату, и такая строчка отбрасывается окном --from/--to.
+func (t *CombinedTokens) Parse(record *Record) error {
+	if err := Validate(t.Addr); err != nil {
+		return err
+	}
+
+	date, _ := ParseDate(t.Date)
+
+	statusCode, _ := strconv.Atoi(t.Status)
+
+	status, err := ParseHTTPStatus(statusCode)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := ParseBytes(t.Bytes)
+	if err != nil {
+		return err
+	}
+
+	*record = Record{
+		Addr:      t.Addr,
+		User:      t.User,
+		Date:      date,
+		Request:   ParseRequest(t.Request),
+		Status:    status,
+		Bytes:     bytes,
+		Referer:   t.Referer,
+		UserAgent: t.UserAgent,
+	}
+
+	return nil
+}
+
+// ParseCombined разбирает строчку в формате "combined" в record без регулярных выражений.
+// Разбор не выделяет память: все строковые поля record ссылаются на саму строчку.
+func ParseCombined(line, field, pattern string, record *Record) (bool, error) {
+	var tokens CombinedTokens
+
+	if !TokenizeCombined(line, &tokens) {
+		return false, ErrInvalidLog
+	}
+
+	if value, ok := tokens.Field(field); ok && !strings.Contains(value, pattern) {
+		return false, nil
+	}
+
+	if err := tokens.Parse(record); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// parseCombined это ParseCombined с сигнатурой Parser.
+func parseCombined(line, field, pattern string) (*Record, bool, error) {
+	record := &Record{}
+
+	ok, err := ParseCombined(line, field, pattern, record)
+	if !ok {
+		return nil, false, err
+	}
+
+	return record, true, nil
+}
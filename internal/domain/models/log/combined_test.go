@@ -0,0 +1,245 @@
+package log
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// combinedRegexp это разбор формата "combined" регулярным выражением, с которым сравнивается токенизатор.
+var combinedRegexp = regexp.MustCompile(`^\s*(?P<addr>\S+) - (?P<user>\S+) \[(?P<date>[^\]]+)\]` +
+	` "(?P<request>(?:[^"\\]|\\.)*)" (?P<status>\d{3}) (?P<bytes>\d+|-) "(?P<referer>[^"]*)" "(?P<user_agent>[^"]*)"`)
+
+// Регулярные выражения, которыми строчки разбирались до TokenizeCombined (см. legacyNew).
+const (
+	legacyLogFormat = `^\s*(?P<addr>\S+) - (?P<user>\S+) \[(?P<date>[^\]]+)\]` +
+		` "(?P<request>\S+ \S+ \S+)" (?P<status>\d{3}) (?P<bytes>\d+|-) "(?P<referer>[^"]*)" "(?P<user_agent>[^"]*)"`
+	legacyDateFormat = `(?P<Day>[1-3][0-9]|0[1-9])\/(?P<Month>[a-zA-Z][a-z]{2})\/` +
+		`(?P<Year>[1-9][0-9]{3}):(?P<Hour>[0-1][0-9]|2[0-4]):(?P<Minutes>[0-5][0-9]):(?P<Seconds>[0-5][0-9]) \+0000`
+)
+
+var combinedLines = []string{
+	`93.180.71.3 - - [17/May/2015:08:05:32 +0000] "GET /downloads/product_1 HTTP/1.1" 304 0 "-" ` +
+		`"Debian APT-HTTP/1.3 (0.8.16~exp12ubuntu10.21)"`,
+	`217.168.17.5 - - [17/May/2015:08:05:34 +0000] "GET /downloads/product_2 HTTP/1.1" 200 490 "-" ` +
+		`"Debian APT-HTTP/1.3 (0.8.10.3)"`,
+	`80.91.33.133 - admin [17/May/2015:08:05:24 +0000] "GET /downloads/product_1?from=mirror HTTP/1.1" 404 336 ` +
+		`"https://example.com/" "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko)"`,
+	`2001:db8::1 - - [17/May/2015:08:05:59 +0000] "POST /api/v1/items HTTP/2.0" 500 12 "-" "curl/7.68.0"`,
+	`10.0.0.7 - - [17/May/2015:08:06:10 +0000] "GET /search?q=\"quoted\" HTTP/1.1" 200 2048 "-" "Wget/1.20.3"`,
+	`10.0.0.8 - - [17/May/2015:08:06:11 +0000] "HEAD /downloads/product_3 HTTP/1.1" 304 - "-" "curl/7.68.0"`,
+	`10.0.0.9 - - [31/Feb/2015:08:06:12 +0000] "GET /downloads/product_1 HTTP/1.1" 200 10 "-" "curl/7.68.0"`,
+}
+
+// parseCombinedRegexp разбирает строчку так же, как ParseCombined, но регулярным выражением.
+func parseCombinedRegexp(line, field, pattern string, record *Record) (bool, error) {
+	tokens, ok := tokenizeCombinedRegexp(line)
+	if !ok {
+		return false, ErrInvalidLog
+	}
+
+	if value, ok := tokens.Field(field); ok && !strings.Contains(value, pattern) {
+		return false, nil
+	}
+
+	if err := tokens.Parse(record); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func tokenizeCombinedRegexp(line string) (CombinedTokens, bool) {
+	matches := combinedRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return CombinedTokens{}, false
+	}
+
+	return CombinedTokens{
+		Addr:      matches[combinedRegexp.SubexpIndex("addr")],
+		User:      matches[combinedRegexp.SubexpIndex("user")],
+		Date:      matches[combinedRegexp.SubexpIndex("date")],
+		Request:   matches[combinedRegexp.SubexpIndex("request")],
+		Status:    matches[combinedRegexp.SubexpIndex("status")],
+		Bytes:     matches[combinedRegexp.SubexpIndex("bytes")],
+		Referer:   matches[combinedRegexp.SubexpIndex("referer")],
+		UserAgent: matches[combinedRegexp.SubexpIndex("user_agent")],
+	}, true
+}
+
+// legacyNew повторяет разбор строчки до TokenizeCombined: регулярные выражения строчки и даты компилируются
+// заново для каждой строчки, а запрос разбирается через http.NewRequest.
+func legacyNew(line string) bool {
+	re := regexp.MustCompile(legacyLogFormat)
+
+	if !re.MatchString(line) {
+		return false
+	}
+
+	matches := re.FindStringSubmatch(line)
+
+	if Validate(matches[re.SubexpIndex("addr")]) != nil {
+		return false
+	}
+
+	_, _ = legacyParseDate(matches[re.SubexpIndex("date")])
+
+	if method, rest, ok := strings.Cut(matches[re.SubexpIndex("request")], " "); ok {
+		target, _, _ := strings.Cut(rest, " ")
+		_, _ = http.NewRequest(method, target, http.NoBody)
+	}
+
+	statusCode, _ := strconv.Atoi(matches[re.SubexpIndex("status")])
+
+	if _, err := ParseHTTPStatus(statusCode); err != nil {
+		return false
+	}
+
+	_, err := ParseBytes(matches[re.SubexpIndex("bytes")])
+
+	return err == nil
+}
+
+func legacyParseDate(date string) (DateFormat, error) {
+	re := regexp.MustCompile(legacyDateFormat)
+
+	if !re.MatchString(date) {
+		return DateFormat{}, ErrDateFormatMismatch
+	}
+
+	matches := re.FindStringSubmatch(date)
+	number := func(name string) int {
+		n, _ := strconv.Atoi(matches[re.SubexpIndex(name)])
+		return n
+	}
+
+	return DateFormat{
+		Day:     number("Day"),
+		Month:   matches[re.SubexpIndex("Month")],
+		Year:    number("Year"),
+		Hour:    number("Hour"),
+		Minutes: number("Minutes"),
+		Seconds: number("Seconds"),
+	}, nil
+}
+
+func TestTokenizeCombined(t *testing.T) {
+	lines := append([]string{
+		`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a HTTP/1.1" 200 10 "-"`,
+		`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a HTTP/1.1" 20 10 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a\" HTTP/1.1" 200 x "-" "curl"`,
+		`10.0.0.1 - - [] "GET /a HTTP/1.1" 200 10 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a HTTP/1.1\" 200 10 "-" "curl"`,
+	}, combinedLines...)
+
+	for _, line := range lines {
+		t.Run(line, func(t *testing.T) {
+			want, wantOK := tokenizeCombinedRegexp(line)
+
+			var got CombinedTokens
+
+			require.Equal(t, wantOK, TokenizeCombined(line, &got))
+
+			if !wantOK {
+				return
+			}
+
+			assert.Equal(t, want, got)
+
+			record := &Record{}
+			ok, err := ParseCombined(line, "", "", record)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			method, rest, _ := strings.Cut(want.Request, " ")
+			target, protocol, _ := strings.Cut(rest, " ")
+			date, _ := ParseDate(want.Date)
+			status, _ := strconv.Atoi(want.Status)
+			bytes, _ := ParseBytes(want.Bytes)
+
+			assert.Equal(t, want.Addr, record.Addr)
+			assert.Equal(t, want.User, record.User)
+			assert.Equal(t, date, record.Date)
+			assert.Equal(t, method, record.Request.Method)
+			assert.Equal(t, target, record.Request.Target)
+			assert.Equal(t, protocol, record.Request.Protocol)
+			assert.Equal(t, status, record.Status.Code)
+			assert.Equal(t, bytes, record.Bytes)
+			assert.Equal(t, want.Referer, record.Referer)
+			assert.Equal(t, want.UserAgent, record.UserAgent)
+		})
+	}
+}
+
+func TestParseCombinedInvalidDate(t *testing.T) {
+	record := &Record{}
+
+	ok, err := ParseCombined(combinedLines[len(combinedLines)-1], "", "", record)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, DateFormat{}, record.Date)
+}
+
+// benchmarkLines запускает parse по строчкам combinedLines. Одна операция бенчмарка это одна строчка.
+func benchmarkLines(b *testing.B, parse func(line string, record *Record) bool) {
+	b.Helper()
+
+	for _, line := range combinedLines {
+		if !parse(line, &Record{}) {
+			b.Fatalf("line is not parsed: %s", line)
+		}
+	}
+
+	size := 0
+	for _, line := range combinedLines {
+		size += len(line)
+	}
+
+	b.SetBytes(int64(size / len(combinedLines)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	record := &Record{}
+
+	for i := range b.N {
+		parse(combinedLines[i%len(combinedLines)], record)
+	}
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "lines/s")
+}
+
+// BenchmarkLegacyNew измеряет разбор, которым строчки разбирались до TokenizeCombined (см. legacyNew).
+func BenchmarkLegacyNew(b *testing.B) {
+	benchmarkLines(b, func(line string, _ *Record) bool {
+		return legacyNew(line)
+	})
+}
+
+// BenchmarkCombinedRegexp измеряет разбор заранее скомпилированным регулярным выражением, который принимает
+// те же строчки, что и TokenizeCombined.
+func BenchmarkCombinedRegexp(b *testing.B) {
+	benchmarkLines(b, func(line string, record *Record) bool {
+		ok, _ := parseCombinedRegexp(line, "", "", record)
+		return ok
+	})
+}
+
+func BenchmarkParseCombined(b *testing.B) {
+	benchmarkLines(b, func(line string, record *Record) bool {
+		ok, _ := ParseCombined(line, "", "", record)
+		return ok
+	})
+}
+
+func BenchmarkTokenizeCombined(b *testing.B) {
+	benchmarkLines(b, func(line string, _ *Record) bool {
+		var tokens CombinedTokens
+		return TokenizeCombined(line, &tokens)
+	})
+}
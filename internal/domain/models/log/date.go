@@ -2,8 +2,6 @@ package log
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
 	"time"
 )
 
@@ -39,8 +37,8 @@ var (
 )
 
 const (
-	dateRegexp = `(?P<Day>[1-3][0-9]|0[1-9])\/(?P<Month>[a-zA-Z][a-z]{2})\/` +
-		`(?P<Year>[1-9][0-9]{3}):(?P<Hour>[0-1][0-9]|2[0-4]):(?P<Minutes>[0-5][0-9]):(?P<Seconds>[0-5][0-9]) \+0000`
+	// dateLayout это вид даты "$time_local", длина которого совпадает с длиной любой корректной даты.
+	dateLayout = "02/Jan/2006:15:04:05 -0700"
 )
 
 var (
@@ -56,68 +54,144 @@ type DateFormat struct {
 	Hour    int
 	Minutes int
 	Seconds int
+	Offset  int // Offset это смещение часового пояса в секундах к востоку от UTC.
 }
 
-func processDate(re *regexp.Regexp, matches []string) (DateFormat, error) {
-	var (
-		daysCnt int
-		ok      bool
-	)
+// parseOffset преобразует смещение часового пояса вида "+0300" или "-0700" в секунды.
+func parseOffset(offset string) int {
+	seconds := number(offset[1:3])*3600 + number(offset[3:5])*60
+	if offset[0] == '-' {
+		return -seconds
+	}
 
-	month := matches[re.SubexpIndex("Month")]
+	return seconds
+}
 
-	if daysCnt, ok = monthToDayCount[month]; !ok {
+// ParseDate преобразует строчку в обертку DateFormat
+// Возвращает ErrDateFormatMismatch, если dateString не удовлетворяет формату
+// Возвращает ErrInvalidDate, если сама дата не может существовать.
+// Строчка разбирается без регулярных выражений и без выделения памяти.
+func ParseDate(dateString string) (DateFormat, error) {
+	if !matchDate(dateString) {
+		return DateFormat{}, ErrDateFormatMismatch
+	}
+
+	month := dateString[3:6]
+
+	daysCnt, ok := monthToDayCount[month]
+	if !ok {
 		return DateFormat{}, ErrInvalidDate
 	}
 
-	year, _ := strconv.Atoi(matches[re.SubexpIndex("Year")])
+	year := number(dateString[7:11])
 
 	if year%4 == 0 && month == "Feb" {
 		daysCnt++
 	}
 
-	day, _ := strconv.Atoi(matches[re.SubexpIndex("Day")])
-	if day < 0 || day > daysCnt {
+	day := number(dateString[0:2])
+	if day > daysCnt {
 		return DateFormat{}, ErrInvalidDate
 	}
 
-	hour, _ := strconv.Atoi(matches[re.SubexpIndex("Hour")])
-
-	minutes, _ := strconv.Atoi(matches[re.SubexpIndex("Minutes")])
-
-	seconds, _ := strconv.Atoi(matches[re.SubexpIndex("Seconds")])
-
 	return DateFormat{
 		Day:     day,
 		Month:   month,
 		Year:    year,
-		Hour:    hour,
-		Minutes: minutes,
-		Seconds: seconds,
+		Hour:    number(dateString[12:14]),
+		Minutes: number(dateString[15:17]),
+		Seconds: number(dateString[18:20]),
+		Offset:  parseOffset(dateString[21:26]),
 	}, nil
 }
 
-// ParseDate преобразует строчку в обертку DateFormat
-// Возвращает ErrDateFormatMismatch, если dateString не удовлетворяет формату
-// Возвращает ErrInvalidDate, если сама дата не может существовать.
-func ParseDate(dateString string) (DateFormat, error) {
-	re := regexp.MustCompile(dateRegexp)
+// matchDate проверяет, что строчка имеет вид "10/Oct/2000:13:55:36 -0700": день от 01 до 39,
+// месяц из трех букв, год от 1000, час от 00 до 24, минуты и секунды от 00 до 59
+// и смещение часового пояса от -1459 до +1459.
+func matchDate(s string) bool {
+	if len(s) != len(dateLayout) {
+		return false
+	}
 
-	if !re.MatchString(dateString) {
-		return DateFormat{}, ErrDateFormatMismatch
+	return (between(s[0], '1', '3') && isDigit(s[1]) || s[0] == '0' && between(s[1], '1', '9')) && s[2] == '/' &&
+		isLetter(s[3]) && isLower(s[4]) && isLower(s[5]) && s[6] == '/' &&
+		between(s[7], '1', '9') && isDigit(s[8]) && isDigit(s[9]) && isDigit(s[10]) && s[11] == ':' &&
+		(between(s[12], '0', '1') && isDigit(s[13]) || s[12] == '2' && between(s[13], '0', '4')) && s[14] == ':' &&
+		between(s[15], '0', '5') && isDigit(s[16]) && s[17] == ':' &&
+		between(s[18], '0', '5') && isDigit(s[19]) && s[20] == ' ' &&
+		(s[21] == '+' || s[21] == '-') &&
+		(s[22] == '0' && isDigit(s[23]) || s[22] == '1' && between(s[23], '0', '4')) &&
+		between(s[24], '0', '5') && isDigit(s[25])
+}
+
+// number преобразует строчку из десятичных цифр в число. Цифры должны быть проверены заранее.
+func number(digits string) int {
+	result := 0
+	for i := range len(digits) {
+		result = result*10 + int(digits[i]-'0')
 	}
 
-	matches := re.FindStringSubmatch(dateString)
+	return result
+}
+
+func between(c, low, high byte) bool {
+	return low <= c && c <= high
+}
+
+func isDigit(c byte) bool {
+	return between(c, '0', '9')
+}
 
-	return processDate(re, matches)
+func isLower(c byte) bool {
+	return between(c, 'a', 'z')
+}
+
+func isLetter(c byte) bool {
+	return isLower(c) || between(c, 'A', 'Z')
 }
 
 func (df *DateFormat) String() string {
-	return fmt.Sprintf("%02d-%s-%02d %02d:%02d:%02d",
-		df.Day, df.Month, df.Year, df.Hour, df.Minutes, df.Seconds)
+	return fmt.Sprintf("%02d-%s-%02d %02d:%02d:%02d %s",
+		df.Day, df.Month, df.Year, df.Hour, df.Minutes, df.Seconds, df.offsetName())
+}
+
+// offsetName возвращает смещение часового пояса в виде "+0300".
+func (df *DateFormat) offsetName() string {
+	sign, offset := '+', df.Offset
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+
+	return fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// Location возвращает часовой пояс даты с фиксированным смещением.
+func (df *DateFormat) Location() *time.Location {
+	if df.Offset == 0 {
+		return time.UTC
+	}
+
+	return time.FixedZone(df.offsetName(), df.Offset)
 }
 
+// ToTime возвращает момент времени с учетом смещения часового пояса, поэтому даты
+// с разными смещениями корректно сравниваются между собой и с --from и --to.
 func (df *DateFormat) ToTime() time.Time {
 	return time.Date(df.Year,
-		time.Month(monthNumber[df.Month]), df.Day, df.Hour, df.Minutes, df.Seconds, 0, time.UTC)
+		time.Month(monthNumber[df.Month]), df.Day, df.Hour, df.Minutes, df.Seconds, 0, df.Location())
+}
+
+// DateFromTime преобразует time.Time в обертку DateFormat, сохраняя смещение часового пояса t.
+func DateFromTime(t time.Time) DateFormat {
+	_, offset := t.Zone()
+
+	return DateFormat{
+		Day:     t.Day(),
+		Month:   t.Month().String()[:3],
+		Year:    t.Year(),
+		Hour:    t.Hour(),
+		Minutes: t.Minute(),
+		Seconds: t.Second(),
+		Offset:  offset,
+	}
 }
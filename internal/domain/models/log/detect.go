@@ -0,0 +1,79 @@
+package log
+
+const (
+	// AutoFormat это имя формата, который определяется автоматически по первым строчкам каждого лога.
+	AutoFormat = "auto"
+)
+
+// NamedParser это Parser встроенного формата вместе с именем формата.
+type NamedParser struct {
+	Name   string
+	Parser Parser
+}
+
+// AutoParser выбирает формат лога среди встроенных форматов по первым строчкам (см. Detect).
+// Если формат не был определен заранее, Parse пробует форматы по очереди для каждой строчки.
+type AutoParser struct {
+	Candidates []NamedParser
+}
+
+// NewAutoParser создает AutoParser, который выбирает между форматами "combined", "common", "vhost_combined"
+// и "json" (ключи JSON сопоставляются полям Record через jsonFields, см. NewJSONParser).
+func NewAutoParser(jsonFields []string) (*AutoParser, error) {
+	common, err := CompileApache(ApacheCommonFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	vhostCombined, err := CompileApache(ApacheVhostCombinedFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	json, err := NewJSONParser(jsonFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AutoParser{
+		Candidates: []NamedParser{
+			{Name: CombinedFormat, Parser: Combined},
+			{Name: CommonFormat, Parser: common},
+			{Name: VhostCombinedFormat, Parser: vhostCombined},
+			{Name: JSONFormat, Parser: json},
+		},
+	}, nil
+}
+
+// Parse разбирает строчку первым подходящим форматом, см. Parser.
+func (a *AutoParser) Parse(line, field, pattern string) (*Record, bool, error) {
+	for _, candidate := range a.Candidates {
+		if record, match, err := candidate.Parser.Parse(line, field, pattern); err == nil {
+			return record, match, nil
+		}
+	}
+
+	return nil, false, ErrInvalidLog
+}
+
+// Detect возвращает формат, который успешно разбирает наибольшую долю строчек lines.
+// При равенстве выбирается формат, который идет раньше в Candidates, в том числе когда не подходит ни один формат.
+func (a *AutoParser) Detect(lines []string) NamedParser {
+	best, bestMatches := a.Candidates[0], -1
+
+	for _, candidate := range a.Candidates {
+		matches := 0
+
+		for _, line := range lines {
+			if _, _, err := candidate.Parser.Parse(line, "", ""); err == nil {
+				matches++
+			}
+		}
+
+		if matches > bestMatches {
+			best, bestMatches = candidate, matches
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,16 @@
+package log
+
+// ParseError сообщает, что строчку лога не удалось разобрать.
+// В отличие от ошибок чтения, после нее чтение лога можно продолжить со следующей строчки.
+type ParseError struct {
+	Text string // Text это строчка лога в том виде, в котором она была прочитана.
+	Err  error  // Err это причина ошибки, например ErrInvalidLog или ErrHTTPStatusCodeNotFound.
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -2,7 +2,7 @@ package log
 
 import (
 	"fmt"
-	"net"
+	"net/netip"
 )
 
 var (
@@ -11,8 +11,11 @@ var (
 
 // Validate принимает строчку и проверяет, можно ли ее преобразовать в IP
 // Возвращает ErrInvalidIP, если ipString не может быть преобразован в IP.
+// В отличие от net.ParseIP, netip.ParseAddr не выделяет память, но допускает зону IPv6 ("fe80::1%eth0"),
+// поэтому такие адреса отбрасываются отдельно.
 func Validate(ipString string) error {
-	if net.ParseIP(ipString) == nil {
+	addr, err := netip.ParseAddr(ipString)
+	if err != nil || addr.Zone() != "" {
 		return ErrInvalidIP
 	}
 
@@ -0,0 +1,232 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// JSONFormat это имя формата логов, в котором каждая строчка является JSON объектом.
+	JSONFormat = "json"
+
+	// epochMillisecondsThreshold отделяет время в секундах от времени в миллисекундах с начала эпохи:
+	// 1e11 секунд это 5138 год, а 1e11 миллисекунд — 1973.
+	epochMillisecondsThreshold = 1e11
+)
+
+var (
+	// defaultJSONFields сопоставляет полям Record ключи JSON объекта по умолчанию.
+	// Поле "request" можно заменить тремя полями "method", "path" и "protocol".
+	defaultJSONFields = map[string]string{
+		"addr":       "remote_addr",
+		"user":       "remote_user",
+		"date":       "time",
+		"request":    "request",
+		"method":     "method",
+		"path":       "path",
+		"protocol":   "protocol",
+		"status":     "status",
+		"bytes":      "body_bytes_sent",
+		"referer":    "http_referer",
+		"user_agent": "http_user_agent",
+	}
+
+	ErrInvalidJSONField = errors.New("invalid json field mapping")
+)
+
+// JSONParser разбирает логи, в которых каждая строчка является JSON объектом.
+// Вложенные ключи задаются через точку: "http.request.method". Все значения, которые не попали в поля Record,
+// сохраняются в Record.Fields под такими же составными ключами.
+type JSONParser struct {
+	fields map[string]string // Ключ JSON объекта для каждого поля Record.
+}
+
+// NewJSONParser создает JSONParser. Каждый элемент mapping имеет вид "поле=ключ", например "date=@timestamp"
+// или "addr=client.ip", и заменяет ключ поля по умолчанию. Поддерживаются поля "addr", "user", "date",
+// "request", "method", "path", "protocol", "status", "bytes", "referer" и "user_agent".
+func NewJSONParser(mapping []string) (*JSONParser, error) {
+	parser := &JSONParser{fields: make(map[string]string, len(defaultJSONFields))}
+
+	for field, key := range defaultJSONFields {
+		parser.fields[field] = key
+	}
+
+	for _, entry := range mapping {
+		field, key, ok := strings.Cut(entry, "=")
+		if _, known := defaultJSONFields[field]; !ok || !known || key == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidJSONField, entry)
+		}
+
+		parser.fields[field] = key
+	}
+
+	return parser, nil
+}
+
+// Parse разбирает строчку лога, см. Parser.
+// Фильтровать можно как по полям Record ("addr", "status", ...), так и по ключам JSON объекта.
+// Поле "request" собирается из полей "method", "path" и "protocol", если ключа запроса целиком в объекте нет.
+func (p *JSONParser) Parse(line, field, pattern string) (*Record, bool, error) {
+	var object map[string]any
+
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return nil, false, ErrInvalidLog
+	}
+
+	values := make(map[string]string)
+	flatten("", object, values)
+
+	if value, ok := p.field(values, field); ok && !strings.Contains(value, pattern) {
+		return nil, false, nil
+	}
+
+	record, err := p.record(values)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return record, true, nil
+}
+
+// flatten раскладывает вложенные объекты и массивы value в values под составными ключами через точку.
+func flatten(prefix string, value any, values map[string]string) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, nested := range typed {
+			flatten(joinKey(prefix, key), nested, values)
+		}
+	case []any:
+		for i, nested := range typed {
+			flatten(joinKey(prefix, strconv.Itoa(i)), nested, values)
+		}
+	case string:
+		values[prefix] = typed
+	case json.Number:
+		values[prefix] = typed.String()
+	case bool:
+		values[prefix] = strconv.FormatBool(typed)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+func (p *JSONParser) record(values map[string]string) (*Record, error) {
+	value := func(field string) string {
+		key := p.fields[field]
+		result := values[key]
+
+		delete(values, key)
+
+		return result
+	}
+
+	record := &Record{
+		Addr:      value("addr"),
+		User:      value("user"),
+		Referer:   value("referer"),
+		UserAgent: value("user_agent"),
+	}
+
+	if record.User == "" {
+		record.User = "-"
+	}
+
+	if err := Validate(record.Addr); err != nil {
+		return nil, err
+	}
+
+	date, err := parseJSONTime(value("date"))
+	if err != nil {
+		return nil, err
+	}
+
+	record.Date = date
+
+	record.Request = ParseRequest(p.request(values))
+
+	for _, field := range []string{"request", "method", "path", "protocol"} {
+		delete(values, p.fields[field])
+	}
+
+	statusCode, err := strconv.Atoi(value("status"))
+	if err != nil {
+		return nil, ErrHTTPStatusCodeNotFound
+	}
+
+	if record.Status, err = ParseHTTPStatus(statusCode); err != nil {
+		return nil, err
+	}
+
+	bytes := value("bytes")
+	if bytes == "" {
+		bytes = "-"
+	}
+
+	if record.Bytes, err = ParseBytes(bytes); err != nil {
+		return nil, err
+	}
+
+	record.Fields = values
+
+	return record, nil
+}
+
+// field возвращает значение поля Record или ключа JSON объекта с именем name для фильтрации.
+func (p *JSONParser) field(values map[string]string, name string) (string, bool) {
+	if name == "request" {
+		return p.request(values), true
+	}
+
+	if key, ok := p.fields[name]; ok {
+		name = key
+	}
+
+	value, ok := values[name]
+
+	return value, ok
+}
+
+// request возвращает "$request": значение ключа запроса целиком или строку из метода, пути и протокола.
+func (p *JSONParser) request(values map[string]string) string {
+	if request := values[p.fields["request"]]; request != "" {
+		return request
+	}
+
+	protocol := values[p.fields["protocol"]]
+	if protocol == "" {
+		protocol = "-"
+	}
+
+	return values[p.fields["method"]] + " " + values[p.fields["path"]] + " " + protocol
+}
+
+// parseJSONTime разбирает время, заданное числом секунд или миллисекунд с начала эпохи,
+// строкой RFC3339 или строкой в формате "$time_local".
+func parseJSONTime(value string) (DateFormat, error) {
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil {
+		if epoch >= epochMillisecondsThreshold {
+			return DateFromTime(time.UnixMilli(int64(epoch)).UTC()), nil
+		}
+
+		return DateFromTime(time.UnixMilli(int64(epoch * 1000)).UTC()), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return DateFromTime(t), nil
+	}
+
+	return ParseDate(value)
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParserFiltersRequestFromParts(t *testing.T) {
+	parser, err := NewJSONParser([]string{"method=http.method", "path=http.path", "protocol=http.version"})
+	require.NoError(t, err)
+
+	line := `{"remote_addr":"10.0.0.1","time":"2015-05-17T08:05:32Z","status":200,"body_bytes_sent":10,` +
+		`"http":{"method":"GET","path":"/downloads/product_1","version":"HTTP/1.1"}}`
+
+	record, ok, err := parser.Parse(line, "request", "GET /downloads")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, "GET /downloads/product_1 HTTP/1.1", record.Request.Raw)
+	assert.Empty(t, record.Fields)
+
+	record, ok, err = parser.Parse(line, "request", "POST")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, record)
+}
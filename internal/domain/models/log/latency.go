@@ -0,0 +1,59 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Latency возвращает время ответа на запрос в секундах. Время берется из дополнительных полей записи:
+// "request_time" (nginx $request_time и Apache %T, в секундах), "request_time_ms" (Apache %{ms}T, в миллисекундах),
+// "request_time_us" (Apache %D, в микросекундах) или "upstream_response_time" (nginx $upstream_response_time). Время ответа нескольких upstream
+// ("0.010, 0.020 : 0.030") складывается. Возвращает false, если времени ответа в записи нет.
+func (r *Record) Latency() (float64, bool) {
+	if value, ok := r.Fields["request_time"]; ok {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+			return seconds, true
+		}
+	}
+
+	if value, ok := r.Fields["request_time_ms"]; ok {
+		if milliseconds, err := strconv.ParseFloat(value, 64); err == nil && milliseconds >= 0 {
+			return milliseconds / 1e3, true
+		}
+	}
+
+	if value, ok := r.Fields["request_time_us"]; ok {
+		if microseconds, err := strconv.ParseFloat(value, 64); err == nil && microseconds >= 0 {
+			return microseconds / 1e6, true
+		}
+	}
+
+	if value, ok := r.Fields["upstream_response_time"]; ok {
+		return upstreamTime(value)
+	}
+
+	return 0, false
+}
+
+// upstreamTime складывает время ответа upstream, записанное через запятые и двоеточия.
+// Значения "-" (upstream не ответил) пропускаются.
+func upstreamTime(value string) (float64, bool) {
+	var (
+		total float64
+		found bool
+	)
+
+	for _, group := range strings.Split(value, ":") {
+		for _, part := range strings.Split(group, ",") {
+			seconds, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+
+			total += seconds
+			found = true
+		}
+	}
+
+	return total, found
+}
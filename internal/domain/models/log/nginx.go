@@ -0,0 +1,288 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nginxVariablePattern = `\$(?:\{([a-zA-Z0-9_]+)\}|([a-zA-Z0-9_]+))`
+)
+
+var (
+	nginxVariableRegexp = regexp.MustCompile(nginxVariablePattern)
+
+	// fieldToNginxVariable сопоставляет поля Record переменным nginx, из которых они заполняются.
+	fieldToNginxVariable = map[string]string{
+		"addr":       "remote_addr",
+		"user":       "remote_user",
+		"date":       "time_local",
+		"request":    "request",
+		"status":     "status",
+		"bytes":      "body_bytes_sent",
+		"referer":    "http_referer",
+		"user_agent": "http_user_agent",
+	}
+
+	// recordNginxVariables это переменные nginx, которые заполняют поля Record,
+	// а не попадают в дополнительные поля Record.Fields.
+	recordNginxVariables = map[string]bool{
+		"remote_addr":     true,
+		"remote_user":     true,
+		"time_local":      true,
+		"time_iso8601":    true,
+		"msec":            true,
+		"request":         true,
+		"request_method":  true,
+		"request_uri":     true,
+		"server_protocol": true,
+		"status":          true,
+		"body_bytes_sent": true,
+		"bytes_sent":      true,
+		"http_referer":    true,
+		"http_user_agent": true,
+	}
+
+	ErrIncompleteFormat = errors.New("log format lacks required variables")
+)
+
+// NginxParser разбирает логи в формате, заданном директивой nginx log_format.
+// Переменные, которые не соответствуют полям Record (например, $request_time, $host, $upstream_addr),
+// сохраняются в Record.Fields под своими именами без "$".
+type NginxParser struct {
+	re        *regexp.Regexp
+	variables []string       // Имена переменных в порядке групп регулярного выражения.
+	index     map[string]int // Номер группы регулярного выражения по имени переменной.
+}
+
+// CompileNginx компилирует строку директивы nginx log_format, например
+// `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent $request_time`.
+// Возвращает ErrIncompleteFormat, если в формате нет адреса, времени, запроса, кода ответа или размера ответа.
+func CompileNginx(format string) (*NginxParser, error) {
+	format = strings.TrimSpace(format)
+
+	return compileNginx(format, nginxVariableRegexp.FindAllStringSubmatchIndex(format, -1))
+}
+
+// compileNginx компилирует формат format, в котором переменные находятся в позициях locations
+// (в том же виде, в котором их возвращает nginxVariableRegexp.FindAllStringSubmatchIndex).
+// Остальной текст формата, в том числе символы "$" вне locations, совпадает сам с собой.
+func compileNginx(format string, locations [][]int) (*NginxParser, error) {
+	parser := &NginxParser{index: make(map[string]int)}
+	expression := strings.Builder{}
+	expression.WriteString(`^\s*`)
+
+	last := 0
+
+	for _, location := range locations {
+		expression.WriteString(regexp.QuoteMeta(format[last:location[0]]))
+
+		name := submatch(format, location, 1)
+		if name == "" {
+			name = submatch(format, location, 2)
+		}
+
+		last = location[1]
+
+		// Значение переменной это самая короткая подстрока, за которой следует весь текст формата
+		// до следующей переменной (а не только его первый символ), и остаток строчки совпадает с форматом.
+		// Поэтому значение может содержать отдельные символы этого текста, например "-" перед " - ".
+		expression.WriteString(`(.*?)`)
+
+		if _, ok := parser.index[name]; !ok {
+			parser.index[name] = len(parser.variables) + 1
+		}
+
+		parser.variables = append(parser.variables, name)
+	}
+
+	expression.WriteString(regexp.QuoteMeta(format[last:]))
+	expression.WriteString(`\s*$`)
+
+	if missing := missingNginxVariables(parser.index); len(missing) != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrIncompleteFormat, strings.Join(missing, ", "))
+	}
+
+	re, err := regexp.Compile(expression.String())
+	if err != nil {
+		return nil, err
+	}
+
+	parser.re = re
+
+	return parser, nil
+}
+
+func submatch(s string, location []int, group int) string {
+	if location[2*group] < 0 {
+		return ""
+	}
+
+	return s[location[2*group]:location[2*group+1]]
+}
+
+func missingNginxVariables(index map[string]int) []string {
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := index[name]; ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var missing []string
+
+	if !has("remote_addr") {
+		missing = append(missing, "$remote_addr")
+	}
+
+	if !has("time_local", "time_iso8601", "msec") {
+		missing = append(missing, "$time_local")
+	}
+
+	if !has("request") && !(has("request_method") && has("request_uri")) {
+		missing = append(missing, "$request")
+	}
+
+	if !has("status") {
+		missing = append(missing, "$status")
+	}
+
+	if !has("body_bytes_sent", "bytes_sent") {
+		missing = append(missing, "$body_bytes_sent")
+	}
+
+	return missing
+}
+
+// Parse разбирает строчку лога, см. Parser.
+// Фильтровать можно как по полям Record ("addr", "status", ...), так и по именам переменных nginx.
+func (p *NginxParser) Parse(line, field, pattern string) (*Record, bool, error) {
+	matches := p.re.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if matches == nil {
+		return nil, false, ErrInvalidLog
+	}
+
+	if value, ok := p.value(matches, field); ok && !strings.Contains(value, pattern) {
+		return nil, false, nil
+	}
+
+	record, err := p.record(matches)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return record, true, nil
+}
+
+// value возвращает значение переменной nginx или поля Record с именем name.
+func (p *NginxParser) value(matches []string, name string) (string, bool) {
+	if variable, ok := fieldToNginxVariable[name]; ok {
+		name = variable
+	}
+
+	index, ok := p.index[name]
+	if !ok {
+		return "", false
+	}
+
+	return matches[index], true
+}
+
+func (p *NginxParser) record(matches []string) (*Record, error) {
+	record := &Record{
+		Fields: make(map[string]string),
+	}
+
+	for i, variable := range p.variables {
+		if !recordNginxVariables[variable] {
+			record.Fields[variable] = matches[i+1]
+		}
+	}
+
+	record.Addr, _ = p.value(matches, "remote_addr")
+	if err := Validate(record.Addr); err != nil {
+		return nil, err
+	}
+
+	record.User, _ = p.value(matches, "remote_user")
+	record.Referer, _ = p.value(matches, "http_referer")
+	record.UserAgent, _ = p.value(matches, "http_user_agent")
+
+	date, err := p.date(matches)
+	if err != nil {
+		return nil, err
+	}
+
+	record.Date = date
+
+	record.Request = ParseRequest(p.request(matches))
+
+	statusValue, _ := p.value(matches, "status")
+
+	statusCode, err := strconv.Atoi(statusValue)
+	if err != nil {
+		return nil, ErrHTTPStatusCodeNotFound
+	}
+
+	if record.Status, err = ParseHTTPStatus(statusCode); err != nil {
+		return nil, err
+	}
+
+	bytesValue, ok := p.value(matches, "body_bytes_sent")
+	if !ok {
+		bytesValue, _ = p.value(matches, "bytes_sent")
+	}
+
+	if record.Bytes, err = ParseBytes(bytesValue); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (p *NginxParser) date(matches []string) (DateFormat, error) {
+	if value, ok := p.value(matches, "time_local"); ok {
+		return ParseDate(value)
+	}
+
+	if value, ok := p.value(matches, "time_iso8601"); ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return DateFormat{}, ErrDateFormatMismatch
+		}
+
+		return DateFromTime(t), nil
+	}
+
+	value, _ := p.value(matches, "msec")
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return DateFormat{}, ErrDateFormatMismatch
+	}
+
+	return DateFromTime(time.UnixMilli(int64(seconds * 1000)).UTC()), nil
+}
+
+func (p *NginxParser) request(matches []string) string {
+	if value, ok := p.value(matches, "request"); ok {
+		return value
+	}
+
+	method, _ := p.value(matches, "request_method")
+	uri, _ := p.value(matches, "request_uri")
+
+	protocol, ok := p.value(matches, "server_protocol")
+	if !ok {
+		protocol = "-"
+	}
+
+	return method + " " + uri + " " + protocol
+}
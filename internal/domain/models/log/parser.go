@@ -0,0 +1,91 @@
+package log
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Parser разбирает строчку лога в определенном формате.
+// Parse работает так же, как New: возвращает Record, true, если строчка проходит фильтрацию
+// по полю field и значению pattern, false в обратном случае, и error, если строчка не соответствует формату.
+type Parser interface {
+	Parse(line, field, pattern string) (*Record, bool, error)
+}
+
+// ParserFunc позволяет использовать обычную функцию как Parser.
+type ParserFunc func(line, field, pattern string) (*Record, bool, error)
+
+func (f ParserFunc) Parse(line, field, pattern string) (*Record, bool, error) {
+	return f(line, field, pattern)
+}
+
+const (
+	// CombinedFormat это имя встроенного формата nginx и Apache "combined".
+	CombinedFormat = "combined"
+	// CommonFormat это имя встроенного формата Apache "common" (без referer и user agent).
+	CommonFormat = "common"
+	// VhostCombinedFormat это имя встроенного формата Apache "vhost_combined".
+	VhostCombinedFormat = "vhost_combined"
+)
+
+var (
+	// Combined разбирает логи в формате nginx "combined" (он совпадает с форматом Apache "combined").
+	Combined Parser = ParserFunc(parseCombined)
+
+	ErrUnknownFormat = errors.New("unknown log format")
+)
+
+// NewParser возвращает Parser для формата format: пустая строка или "combined" означают
+// встроенный формат "combined", "common" и "vhost_combined" — встроенные форматы Apache,
+// строка с переменными вида $name — директиву nginx log_format (даже если в ней встречается "%" с буквой),
+// иначе строка с директивами вида %h — директиву Apache LogFormat,
+// "json" — JSON объекты по одному на строчку, ключи которых сопоставляются полям Record через jsonFields (см. NewJSONParser),
+// "auto" — автоматическое определение одного из встроенных форматов (см. AutoParser).
+func NewParser(format string, jsonFields []string) (Parser, error) {
+	switch {
+	case format == "" || format == CombinedFormat:
+		return Combined, nil
+	case format == JSONFormat:
+		return NewJSONParser(jsonFields)
+	case format == AutoFormat:
+		return NewAutoParser(jsonFields)
+	case format == CommonFormat:
+		return CompileApache(ApacheCommonFormat)
+	case format == VhostCombinedFormat:
+		return CompileApache(ApacheVhostCombinedFormat)
+	case nginxVariableRegexp.MatchString(format):
+		return CompileNginx(format)
+	case apacheDirectiveRegexp.MatchString(format):
+		return CompileApache(format)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Field возвращает значение поля записи по его имени: "addr", "user", "date", "request", "status",
+// "bytes", "referer", "user_agent" или имя дополнительного поля из Fields.
+// Возвращает false, если такого поля нет.
+func (r *Record) Field(name string) (string, bool) {
+	switch name {
+	case "addr":
+		return r.Addr, true
+	case "user":
+		return r.User, true
+	case "date":
+		return r.Date.String(), true
+	case "request":
+		return r.Request.Raw, true
+	case "status":
+		return strconv.Itoa(r.Status.Code), true
+	case "bytes":
+		return strconv.Itoa(r.Bytes), true
+	case "referer":
+		return r.Referer, true
+	case "user_agent":
+		return r.UserAgent, true
+	}
+
+	value, ok := r.Fields[name]
+
+	return value, ok
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewParserPrefersNginxVariables(t *testing.T) {
+	parser, err := NewParser(`$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent 50%off`, nil)
+	require.NoError(t, err)
+	require.IsType(t, &NginxParser{}, parser)
+
+	record, ok, err := parser.Parse(`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a HTTP/1.1" 200 10 50%off`, "", "")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, "/a", record.Request.Path)
+	assert.Equal(t, 10, record.Bytes)
+}
+
+func TestCompileApacheLiteralDollar(t *testing.T) {
+	parser, err := CompileApache(`%h %l %u %t "%r" %>s %b $cost %D`)
+	require.NoError(t, err)
+
+	record, ok, err := parser.Parse(`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a HTTP/1.1" 200 10 $cost 1500`, "", "")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, map[string]string{"ident": "-", "request_time_us": "1500"}, record.Fields)
+
+	_, _, err = parser.Parse(`10.0.0.1 - - [17/May/2015:08:06:10 +0000] "GET /a HTTP/1.1" 200 10 12.50 1500`, "", "")
+	assert.ErrorIs(t, err, ErrInvalidLog)
+}
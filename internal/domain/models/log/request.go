@@ -1,43 +1,149 @@
 package log
 
 import (
-	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
-var (
-	ErrInvalidRequest = fmt.Errorf("invalid request")
-)
+// commonMethods это методы, которыми пользуются обычные клиенты. Остальные методы (TRACE, PROPFIND, CONNECT и т. д.)
+// считаются необычными: чаще всего их присылают сканеры уязвимостей.
+var commonMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
 
 // RequestFormat это обертка над "$request" в логе.
 type RequestFormat struct {
-	// Request хранит запрос в формате *http.Request.
-	Request *http.Request
+	// Method хранит метод запроса, например "GET". Пустой, если "$request" не похож на строку запроса.
+	Method string
+	// Target хранит цель запроса в том виде, в котором она записана в логе: "/path?query", "*",
+	// "host:443" для CONNECT или абсолютный URL для запросов к прокси.
+	Target string
+	// Path хранит путь цели запроса без строки запроса.
+	Path string
+	// Query хранит строку запроса без "?".
+	Query string
 	// Protocol хранит значение протокола.
 	Protocol string
+	// Raw хранит "$request" в том виде, в котором он записан в логе.
+	Raw string
 }
 
-// ParseRequest принимает часть лога, находящуюся в "$request" и возвращает обертку
-// RequestFormat, а также error, если что-то пошло не так.
-func ParseRequest(request string) (RequestFormat, error) {
-	parts := strings.SplitN(request, " ", 3)
+// ParseRequest принимает часть лога, находящуюся в "$request" и возвращает обертку RequestFormat.
+// Разбор нестрогий и не выделяет память: цель запроса не проверяется, протокол может отсутствовать,
+// а то, что не похоже на строку запроса (например, начало TLS рукопожатия "\x16\x03..."
+// от сканеров), сохраняется только в Raw.
+func ParseRequest(request string) RequestFormat {
+	method, rest, _ := strings.Cut(request, " ")
+	if !isToken(method) {
+		return RequestFormat{Raw: request}
+	}
 
-	if len(parts) != 3 {
-		return RequestFormat{}, ErrInvalidRequest
+	target, protocol, _ := strings.Cut(rest, " ")
+	path, query := splitTarget(target)
+
+	return RequestFormat{
+		Method:   method,
+		Target:   target,
+		Path:     path,
+		Query:    query,
+		Protocol: protocol,
+		Raw:      request,
 	}
+}
+
+// splitTarget разделяет цель запроса на путь и строку запроса.
+// У абсолютного URL ("http://host/path?query") отбрасываются схема и хост.
+func splitTarget(target string) (string, string) {
+	if scheme := strings.Index(target, "://"); scheme > 0 && !strings.HasPrefix(target, "/") {
+		target = target[scheme+len("://"):]
 
-	method := parts[0]
-	rawURL := parts[1]
-	protocol := parts[2]
+		start := strings.IndexAny(target, "/?")
+		if start == -1 {
+			return "/", ""
+		}
 
-	req, err := http.NewRequest(method, rawURL, http.NoBody)
-	if err != nil {
-		return RequestFormat{}, err
+		target = target[start:]
+		if target[0] == '?' {
+			return "/", target[1:]
+		}
 	}
 
-	return RequestFormat{
-		Request:  req,
-		Protocol: protocol,
-	}, nil
+	path, query, _ := strings.Cut(target, "?")
+
+	return path, query
+}
+
+// isToken проверяет, что method является токеном HTTP (RFC 9110), как того требует net/http.
+func isToken(method string) bool {
+	if method == "" {
+		return false
+	}
+
+	for i := range len(method) {
+		c := method[i]
+		if !isLetter(c) && !isDigit(c) && !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Resource возвращает имя ресурса, по которому запросы объединяются в статистике:
+// путь без строки запроса или, если keepQuery, вместе с ней.
+// Для "$request", который не похож на строку запроса, возвращает "-".
+func (r *RequestFormat) Resource(keepQuery bool) string {
+	if r.Path == "" {
+		return "-"
+	}
+
+	if keepQuery && r.Query != "" {
+		return r.Path + "?" + r.Query
+	}
+
+	return r.Path
+}
+
+// MethodName возвращает метод запроса для статистики или "-", если "$request" не похож на строку запроса.
+func (r *RequestFormat) MethodName() string {
+	if r.Method == "" {
+		return "-"
+	}
+
+	return r.Method
+}
+
+// ProtocolName возвращает протокол запроса для статистики или "-", если протокол не указан.
+func (r *RequestFormat) ProtocolName() string {
+	if r.Protocol == "" {
+		return "-"
+	}
+
+	return r.Protocol
+}
+
+// IsUnusualMethod сообщает, что метод method не используется обычными клиентами (GET, HEAD, POST, PUT, PATCH,
+// DELETE и OPTIONS). Такие методы, как и мусор вместо строки запроса ("-"), часто означают сканирование.
+func IsUnusualMethod(method string) bool {
+	return !commonMethods[method]
+}
+
+// URL разбирает цель запроса в *url.URL так же, как это делает HTTP сервер из net/http.
+// Разбор выполняется только при вызове, поэтому некорректная цель не мешает учитывать запрос в статистике.
+func (r *RequestFormat) URL() (*url.URL, error) {
+	switch {
+	case r.Method == http.MethodConnect && !strings.HasPrefix(r.Target, "/"):
+		return &url.URL{Host: r.Target}, nil
+	case r.Target == "*":
+		return &url.URL{Path: "*"}, nil
+	}
+
+	return url.ParseRequestURI(r.Target)
 }
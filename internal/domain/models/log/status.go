@@ -101,3 +101,20 @@ func ParseHTTPStatus(code int) (HTTPStatus, error) {
 
 	return HTTPStatus{}, ErrHTTPStatusCodeNotFound
 }
+
+// statusClasses это имена классов кодов ответа по первой цифре кода.
+var statusClasses = [...]string{"0xx", "1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// Class возвращает класс кода ответа: "1xx", "2xx", "3xx", "4xx" или "5xx".
+func (s HTTPStatus) Class() string {
+	if s.Code < 0 || s.Code/100 >= len(statusClasses) {
+		return "-"
+	}
+
+	return statusClasses[s.Code/100]
+}
+
+// IsError сообщает, означает ли код ответа ошибку клиента (4xx) или сервера (5xx).
+func (s HTTPStatus) IsError() bool {
+	return s.Code >= 400
+}
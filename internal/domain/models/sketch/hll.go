@@ -0,0 +1,189 @@
+package sketch
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// DefaultPrecision это количество бит хеша, по которым выбирается регистр HyperLogLog по умолчанию:
+	// 2^14 регистров занимают 16 КиБ и дают стандартную ошибку около 0.8%.
+	DefaultPrecision = 14
+
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// HyperLogLog это потоковый алгоритм приближенного подсчета количества различных значений.
+// Каждое значение хешируется, старшие Precision бит хеша выбирают регистр, а в регистре сохраняется
+// наибольшая позиция первой единицы в остальных битах. Память зависит только от Precision, а не от количества
+// значений, стандартная ошибка оценки равна 1.04/√(2^Precision). HyperLogLog с одинаковой точностью
+// объединяются без потери точности, поэтому их можно собирать по частям на разных воркерах
+// и сохранять в файле состояния.
+type HyperLogLog struct {
+	Precision uint8   // Количество бит хеша, по которым выбирается регистр.
+	Registers []uint8 // Наибольшая позиция первой единицы в хешах значений каждого регистра.
+}
+
+// NewHyperLogLog создает пустой HyperLogLog с 2^precision регистрами.
+// Если precision не лежит в диапазоне от 4 до 18, используется DefaultPrecision.
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 || precision > 18 {
+		precision = DefaultPrecision
+	}
+
+	return &HyperLogLog{
+		Precision: precision,
+		Registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add учитывает значение value.
+func (h *HyperLogLog) Add(value string) {
+	h.addHash(mix(fnv(fnvOffset, value)))
+}
+
+// AddPair учитывает пару значений first и second как одно значение.
+func (h *HyperLogLog) AddPair(first, second string) {
+	h.addHash(mix(fnv(fnv(fnv(fnvOffset, first), "\x00"), second)))
+}
+
+// Merge добавляет к HyperLogLog значения other. Если точность отличается, результат
+// получает меньшую из них, и регистры с большей точностью сворачиваются.
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	if other == nil || len(other.Registers) == 0 {
+		return
+	}
+
+	if other.Precision < h.Precision {
+		h.fold(other.Precision)
+	}
+
+	shift := other.Precision - h.Precision
+
+	for index, register := range other.Registers {
+		if shift > 0 {
+			register = foldedRegister(uint64(index), register, shift)
+		}
+
+		h.Registers[index>>shift] = max(h.Registers[index>>shift], register)
+	}
+}
+
+// Estimate возвращает оценку количества различных значений. Используется оценка Ertl (2017),
+// которая не требует отдельной поправки для малого и большого количества значений.
+func (h *HyperLogLog) Estimate() int {
+	q := 64 - int(h.Precision)
+	m := float64(len(h.Registers))
+
+	counts := make([]int, q+2)
+	for _, register := range h.Registers {
+		counts[register]++
+	}
+
+	z := m * tau(1-float64(counts[q+1])/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + float64(counts[k]))
+	}
+
+	z += m * sigma(float64(counts[0])/m)
+
+	return int(math.Round(m * m / (2 * math.Ln2 * z)))
+}
+
+func (h *HyperLogLog) addHash(hash uint64) {
+	index := hash >> (64 - h.Precision)
+	rank := uint8(bits.LeadingZeros64(hash<<h.Precision|1<<(h.Precision-1))) + 1
+
+	h.Registers[index] = max(h.Registers[index], rank)
+}
+
+// fold уменьшает точность до precision, объединяя регистры с общими старшими битами номера.
+func (h *HyperLogLog) fold(precision uint8) {
+	shift := h.Precision - precision
+	registers := make([]uint8, 1<<precision)
+
+	for index, register := range h.Registers {
+		register = foldedRegister(uint64(index), register, shift)
+		registers[index>>shift] = max(registers[index>>shift], register)
+	}
+
+	h.Precision, h.Registers = precision, registers
+}
+
+// foldedRegister возвращает значение регистра index после уменьшения точности на shift бит:
+// младшие shift бит номера становятся старшими битами остатка хеша.
+func foldedRegister(index uint64, register, shift uint8) uint8 {
+	if register == 0 {
+		return 0
+	}
+
+	low := index & (1<<shift - 1)
+	if low != 0 {
+		return uint8(bits.LeadingZeros64(low<<(64-shift))) + 1
+	}
+
+	return register + shift
+}
+
+// fnv продолжает хеш FNV-1a hash байтами s.
+func fnv(hash uint64, s string) uint64 {
+	for i := range len(s) {
+		hash ^= uint64(s[i])
+		hash *= fnvPrime
+	}
+
+	return hash
+}
+
+// mix перемешивает биты хеша (финализатор MurmurHash3), чтобы близкие строки вроде IP-адресов
+// равномерно распределялись по регистрам.
+func mix(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+
+	return hash
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y, z := 1.0, x
+
+	for {
+		x *= x
+
+		previous := z
+		z += x * y
+		y += y
+
+		if z == previous {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y, z := 1.0, 1-x
+
+	for {
+		x = math.Sqrt(x)
+
+		previous := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+
+		if z == previous {
+			return z / 3
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package sketch
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultRelativeError это относительная погрешность квантилей по умолчанию (1%).
+	DefaultRelativeError = 0.01
+
+	// minIndexable это наименьшее значение, которое попадает в логарифмические интервалы.
+	// Меньшие значения (в том числе нули и отрицательные) учитываются в отдельном интервале.
+	minIndexable = 1e-9
+)
+
+// Sketch это потоковый DDSketch для приближенного подсчета квантилей неотрицательных значений.
+// Значения раскладываются по интервалам (γ^(i-1), γ^i], где γ = (1+α)/(1-α), поэтому любой квантиль
+// возвращается с относительной погрешностью не больше α, а память зависит только от разброса значений
+// (около 1400 интервалов на диапазон от 1 байта до 1 терабайта при α = 1%), но не от их количества.
+// Sketch с одинаковой погрешностью объединяются без потери точности, поэтому их можно собирать
+// по частям на разных воркерах и сохранять в файле состояния.
+type Sketch struct {
+	RelativeError float64     // Относительная погрешность квантилей α.
+	Bins          map[int]int // Количество значений в каждом интервале по его номеру i.
+	Zeros         int         // Количество значений меньше minIndexable.
+	Count         int         // Общее количество значений.
+	Sum           float64     // Сумма значений.
+	Min           float64     // Минимальное значение.
+	Max           float64     // Максимальное значение.
+}
+
+// New создает пустой Sketch с относительной погрешностью relativeError.
+// Если relativeError не лежит в интервале (0, 1), используется DefaultRelativeError.
+func New(relativeError float64) *Sketch {
+	if relativeError <= 0 || relativeError >= 1 {
+		relativeError = DefaultRelativeError
+	}
+
+	return &Sketch{
+		RelativeError: relativeError,
+		Bins:          make(map[int]int),
+	}
+}
+
+// Add учитывает одно значение.
+func (s *Sketch) Add(value float64) {
+	if s.Count == 0 || value < s.Min {
+		s.Min = value
+	}
+
+	if s.Count == 0 || value > s.Max {
+		s.Max = value
+	}
+
+	s.Count++
+	s.Sum += value
+
+	s.addToBin(value, 1)
+}
+
+// Merge добавляет к Sketch значения other. Если погрешности отличаются, значения other
+// переносятся через середины их интервалов, и погрешность результата складывается из обеих.
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+
+	s.Count += other.Count
+	s.Sum += other.Sum
+	s.Zeros += other.Zeros
+
+	if s.Bins == nil {
+		s.Bins = make(map[int]int)
+	}
+
+	for index, cnt := range other.Bins {
+		if other.RelativeError == s.RelativeError {
+			s.Bins[index] += cnt
+		} else {
+			s.addToBin(other.binValue(index), cnt)
+		}
+	}
+}
+
+// Quantile возвращает значение квантиля q от 0 до 1 с относительной погрешностью не больше RelativeError.
+// Квантиль выбирается так же, как элемент с индексом ⌊q·(Count-1)⌋ в отсортированных значениях.
+// Для пустого Sketch возвращает 0.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	rank := int(q * float64(s.Count-1))
+
+	cumulative := s.Zeros
+	if rank < cumulative {
+		return s.Min
+	}
+
+	indexes := make([]int, 0, len(s.Bins))
+	for index := range s.Bins {
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		cumulative += s.Bins[index]
+		if rank < cumulative {
+			return min(max(s.binValue(index), s.Min), s.Max)
+		}
+	}
+
+	return s.Max
+}
+
+// Mean возвращает среднее значение. Оно считается по точной сумме значений, без погрешности.
+func (s *Sketch) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Sum / float64(s.Count)
+}
+
+func (s *Sketch) addToBin(value float64, cnt int) {
+	if value < minIndexable {
+		s.Zeros += cnt
+		return
+	}
+
+	if s.Bins == nil {
+		s.Bins = make(map[int]int)
+	}
+
+	s.Bins[int(math.Ceil(math.Log(value)/s.logGamma()))] += cnt
+}
+
+// binValue возвращает значение интервала с номером index, относительная погрешность которого
+// для любого значения из интервала не больше RelativeError.
+func (s *Sketch) binValue(index int) float64 {
+	gamma := s.gamma()
+
+	return 2 * math.Pow(gamma, float64(index)) / (gamma + 1)
+}
+
+func (s *Sketch) gamma() float64 {
+	return (1 + s.RelativeError) / (1 - s.RelativeError)
+}
+
+func (s *Sketch) logGamma() float64 {
+	return math.Log(s.gamma())
+}
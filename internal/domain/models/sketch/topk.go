@@ -0,0 +1,202 @@
+package sketch
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Counter это счетчик ключа в TopK.
+type Counter struct {
+	Count int // Оценка количества сверху: точное количество не больше Count.
+	Error int // Наибольшее превышение точного количества: точное количество не меньше Count-Error.
+}
+
+// TopK это потоковый алгоритм Space-Saving для приближенного подсчета самых частых ключей.
+// Он хранит не больше Capacity счетчиков: новый ключ при заполненных счетчиках занимает место ключа
+// с наименьшим счетчиком и наследует его значение как погрешность. Поэтому память не зависит от количества
+// различных ключей, количество любого ключа переоценивается не больше чем на N/Capacity (N — общее количество),
+// а любой ключ, который встречается чаще N/Capacity раз, гарантированно остается среди счетчиков.
+// TopK объединяются с сохранением этих гарантий, поэтому их можно собирать по частям на разных воркерах
+// и сохранять в файле состояния.
+type TopK struct {
+	Capacity int                 // Наибольшее количество счетчиков.
+	Counters map[string]*Counter // Счетчики отслеживаемых ключей.
+
+	heap counterHeap // Счетчики по возрастанию Count, восстанавливается по Counters при необходимости.
+}
+
+// NewTopK создает пустой TopK, который хранит не больше capacity счетчиков.
+func NewTopK(capacity int) *TopK {
+	return &TopK{
+		Capacity: max(capacity, 1),
+		Counters: make(map[string]*Counter, capacity),
+	}
+}
+
+// Add учитывает одно появление ключа key. Если ради него пришлось перестать отслеживать другой ключ,
+// возвращает этот ключ и true.
+func (t *TopK) Add(key string) (evicted string, ok bool) {
+	t.restoreHeap()
+
+	if counter, found := t.Counters[key]; found {
+		counter.Count++
+		heap.Fix(&t.heap, t.heap.index[key])
+
+		return "", false
+	}
+
+	if len(t.Counters) < t.Capacity {
+		counter := &Counter{Count: 1}
+		t.Counters[key] = counter
+		heap.Push(&t.heap, heapEntry{key: key, counter: counter})
+
+		return "", false
+	}
+
+	smallest := t.heap.entries[0]
+	counter := &Counter{Count: smallest.counter.Count + 1, Error: smallest.counter.Count}
+
+	delete(t.Counters, smallest.key)
+	delete(t.heap.index, smallest.key)
+
+	t.Counters[key] = counter
+	t.heap.entries[0] = heapEntry{key: key, counter: counter}
+	t.heap.index[key] = 0
+	heap.Fix(&t.heap, 0)
+
+	return smallest.key, true
+}
+
+// Merge добавляет к TopK ключи other. Ключ, которого нет среди заполненных счетчиков одного из TopK,
+// мог встретиться в нем не больше MinCount раз, поэтому MinCount добавляется и к его количеству,
+// и к погрешности. После объединения остаются Capacity ключей с наибольшими счетчиками.
+func (t *TopK) Merge(other *TopK) {
+	if other == nil || len(other.Counters) == 0 {
+		return
+	}
+
+	if t.Counters == nil {
+		t.Counters = make(map[string]*Counter)
+	}
+
+	ownMin, otherMin := t.MinCount(), other.MinCount()
+
+	for key, counter := range t.Counters {
+		if otherCounter, ok := other.Counters[key]; ok {
+			counter.Count += otherCounter.Count
+			counter.Error += otherCounter.Error
+		} else {
+			counter.Count += otherMin
+			counter.Error += otherMin
+		}
+	}
+
+	for key, otherCounter := range other.Counters {
+		if _, ok := t.Counters[key]; !ok {
+			t.Counters[key] = &Counter{Count: otherCounter.Count + ownMin, Error: otherCounter.Error + ownMin}
+		}
+	}
+
+	if len(t.Counters) > t.Capacity {
+		for _, key := range t.Keys()[t.Capacity:] {
+			delete(t.Counters, key)
+		}
+	}
+
+	t.heap = counterHeap{}
+}
+
+// MinCount возвращает наименьший счетчик, если все Capacity счетчиков заняты, иначе 0.
+// Это граница погрешности: любой ключ встречался не больше Count раз, если он отслеживается,
+// и не больше MinCount раз, если нет.
+func (t *TopK) MinCount() int {
+	if len(t.Counters) < t.Capacity {
+		return 0
+	}
+
+	t.restoreHeap()
+
+	return t.heap.entries[0].counter.Count
+}
+
+// Keys возвращает отслеживаемые ключи по убыванию счетчиков.
+// Ключи с одинаковыми счетчиками сортируются по возрастанию.
+func (t *TopK) Keys() []string {
+	keys := make([]string, 0, len(t.Counters))
+	for key := range t.Counters {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if t.Counters[keys[i]].Count != t.Counters[keys[j]].Count {
+			return t.Counters[keys[i]].Count > t.Counters[keys[j]].Count
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// restoreHeap восстанавливает кучу счетчиков, если она не соответствует Counters
+// (например, после Merge или загрузки TopK из файла состояния).
+func (t *TopK) restoreHeap() {
+	if t.Counters == nil {
+		t.Counters = make(map[string]*Counter)
+	}
+
+	if t.heap.index != nil && len(t.heap.entries) == len(t.Counters) {
+		return
+	}
+
+	t.heap = counterHeap{
+		entries: make([]heapEntry, 0, len(t.Counters)),
+		index:   make(map[string]int, len(t.Counters)),
+	}
+
+	for key, counter := range t.Counters {
+		t.heap.index[key] = len(t.heap.entries)
+		t.heap.entries = append(t.heap.entries, heapEntry{key: key, counter: counter})
+	}
+
+	heap.Init(&t.heap)
+}
+
+type heapEntry struct {
+	key     string
+	counter *Counter
+}
+
+// counterHeap это куча счетчиков по возрастанию Count, которая помнит позицию каждого ключа.
+type counterHeap struct {
+	entries []heapEntry
+	index   map[string]int
+}
+
+func (h *counterHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *counterHeap) Less(i, j int) bool {
+	return h.entries[i].counter.Count < h.entries[j].counter.Count
+}
+
+func (h *counterHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.index[h.entries[i].key] = i
+	h.index[h.entries[j].key] = j
+}
+
+func (h *counterHeap) Push(x any) {
+	entry := x.(heapEntry)
+	h.index[entry.key] = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *counterHeap) Pop() any {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	delete(h.index, last.key)
+
+	return last
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+)
+
+const (
+	OnErrorFail       = "fail"       // Прервать обработку на первой строчке, которую не удалось разобрать.
+	OnErrorSkip       = "skip"       // Пропустить строчку и учесть ее в разделе "Parse errors".
+	OnErrorQuarantine = "quarantine" // Пропустить строчку и записать ее в файл отклоненных строчек.
+)
+
+var (
+	ErrUnknownErrorPolicy = errors.New("unknown error policy")
+)
+
+// ErrorHandler решает, что делать со строчками, которые не удалось разобрать.
+// Может использоваться из нескольких горутин одновременно.
+type ErrorHandler struct {
+	policy string
+
+	mu         sync.Mutex
+	quarantine io.Writer // quarantine получает отклоненные строчки при политике OnErrorQuarantine.
+}
+
+// NewErrorHandler создает ErrorHandler с политикой policy: OnErrorFail, OnErrorSkip или OnErrorQuarantine.
+// Для OnErrorQuarantine отклоненные строчки записываются в quarantine.
+// Возвращает ErrUnknownErrorPolicy, если политика неизвестна.
+func NewErrorHandler(policy string, quarantine io.Writer) (*ErrorHandler, error) {
+	switch policy {
+	case OnErrorFail, OnErrorSkip, OnErrorQuarantine:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownErrorPolicy, policy)
+	}
+
+	return &ErrorHandler{policy: policy, quarantine: quarantine}, nil
+}
+
+// Handle обрабатывает строчку номер line лога name, которую не удалось разобрать.
+// При политике OnErrorFail (и для nil ErrorHandler) возвращает ошибку с именем лога и номером строчки,
+// в остальных случаях учитывает строчку в статистике bank.
+func (h *ErrorHandler) Handle(name string, line int64, parseErr *log.ParseError, bank *analyzer.Statistics) error {
+	if h == nil || h.policy == OnErrorFail {
+		return fmt.Errorf("%s:%d: %w", name, line, parseErr)
+	}
+
+	bank.LinesRead++
+	bank.AddParseError(name, parseErr.Error(), 1)
+
+	if h.policy != OnErrorQuarantine {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := fmt.Fprintf(h.quarantine, "%s:%d: %s: %s\n", name, line, parseErr, strings.TrimRight(parseErr.Text, "\r\n"))
+
+	return err
+}
@@ -12,11 +12,15 @@ import (
 )
 
 // Run обрабатывает логи из LogReader в указанном временном диапазоне и собирает статистику.
+// Строчки, которые не удалось разобрать, передаются в onError вместе с именем лога name и номером строчки.
 // Возвращает error, если что-то пошло не так.
-func Run(reader input.LogReader, from, to time.Time, bank *analyzer.Statistics) error {
+func Run(reader input.LogReader, name string, from, to time.Time, bank *analyzer.Statistics,
+	onError *ErrorHandler) error {
 	var (
 		err       error
 		logRecord *log.Record
+		lines     int64
+		firstLine int64 = -1
 	)
 
 	for {
@@ -26,25 +30,98 @@ func Run(reader input.LogReader, from, to time.Time, bank *analyzer.Statistics)
 				break
 			}
 
-			return err
-		}
+			var parseErr *log.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
 
-		if logRecord == nil {
-			continue
-		}
+			lines++
+
+			// Номер первой строчки может быть дорогим (например, для части файла), поэтому он узнается только при ошибке.
+			if firstLine < 0 {
+				if firstLine, err = FirstLine(reader); err != nil {
+					return err
+				}
+			}
+
+			if err := onError.Handle(name, firstLine+lines, parseErr, bank); err != nil {
+				return err
+			}
 
-		formattedDate := logRecord.Date.ToTime()
-		if from.After(formattedDate) || to.Before(formattedDate) {
 			continue
 		}
 
-		bank.RequestsCount.Values[logRecord.Status.Code]++
-		bank.ResourcesCount.Values[logRecord.Request.Request.URL.String()]++
-		bank.IPCount.Values[logRecord.Addr]++
+		lines++
 
-		bank.ByteSizes = append(bank.ByteSizes, logRecord.Bytes)
-		bank.ByteSize.Add(bank.ByteSize, big.NewInt(int64(logRecord.Bytes)))
+		Collect(logRecord, from, to, bank)
 	}
 
 	return nil
 }
+
+// FirstLine возвращает количество строчек лога до позиции, с которой reader начал чтение.
+func FirstLine(reader input.LogReader) (int64, error) {
+	if numberer, ok := reader.(input.LineNumberer); ok {
+		return numberer.FirstLine()
+	}
+
+	return 0, nil
+}
+
+// Collect добавляет один лог в статистику, если он попадает в указанный временной диапазон.
+// logRecord равен nil для строчек, которые не прошли фильтрацию, они учитываются только в количестве прочитанных строчек.
+func Collect(logRecord *log.Record, from, to time.Time, bank *analyzer.Statistics) {
+	bank.LinesRead++
+
+	if logRecord == nil {
+		return
+	}
+
+	formattedDate := logRecord.Date.ToTime()
+	if from.After(formattedDate) || to.Before(formattedDate) {
+		return
+	}
+
+	bank.RequestsCount.Values[logRecord.Status.Code]++
+	resource := logRecord.Request.Resource(bank.Options.KeepQuery)
+
+	if evicted, ok := bank.ResourcesCount.Add(resource); ok {
+		delete(bank.Latency.Resources, evicted)
+	}
+
+	bank.IPCount.Add(logRecord.Addr)
+	bank.Cardinality.Add(logRecord.Addr, logRecord.UserAgent, resource)
+	bank.Status.Add(resource, logRecord.Status.Class())
+
+	method := logRecord.Request.MethodName()
+	bank.Methods.Add(method, logRecord.Bytes, logRecord.Status.IsError(), log.IsUnusualMethod(method))
+	bank.Protocols.Values[logRecord.Request.ProtocolName()]++
+
+	if bank.GroupBy.Field != "" {
+		value, ok := logRecord.Field(bank.GroupBy.Field)
+
+		if bank.GroupBy.Field == "date" && bank.Options.Location != nil {
+			date := log.DateFromTime(formattedDate.In(bank.Options.Location))
+			value = date.String()
+		}
+
+		if !ok || value == "" {
+			value = "-"
+		}
+
+		bank.GroupBy.Values[value]++
+	}
+
+	bank.Traffic.Add(formattedDate, bank.Options.Location, logRecord.Bytes, logRecord.Status.IsError())
+
+	bank.Sizes.Add(float64(logRecord.Bytes))
+	bank.ByteSize.Add(bank.ByteSize, big.NewInt(int64(logRecord.Bytes)))
+
+	if latency, ok := logRecord.Latency(); ok {
+		bank.Latency.Add(logRecord.Status.Class(), latency)
+
+		if bank.ResourcesCount.Tracked(resource) {
+			bank.Latency.AddResource(resource, latency)
+		}
+	}
+}
@@ -13,8 +13,17 @@ import (
 const (
 	CommonInformationADOCHeader    = "|Metrics |Value"
 	ResourcesInformationADOCHeader = "|Resource |Count"
+	ResourcesApproximateADOCHeader = "|Resource |Count (approx.)"
 	RequestCodesADOCHeader         = "|Code |Name |Count"
 	IPCountADOCHeader              = "|IP |Count"
+	IPCountApproximateADOCHeader   = "|IP |Count (approx.)"
+	GroupByADOCHeader              = "|%s |Count"
+	ParseErrorsADOCHeader          = "|File |Reason |Count"
+	LatencyHistogramADOCHeader     = "|Latency |Count |Share"
+	TrafficADOCHeader              = "|Time |Requests |Bytes |Errors"
+	StatusClassesADOCHeader        = "|Class |Count |Share"
+	MethodsADOCHeader              = "|Method |Count |Share |Bytes |Error rate"
+	ProtocolsADOCHeader            = "|Protocol |Count |Share"
 	ADOCHeader                     = "===="
 	ADOCTableSymbol                = "|==="
 )
@@ -33,7 +42,7 @@ func AddADOCCommonInformation(sb *strings.Builder, stats *analyzer.Statistics) {
 
 	common := OutputToCommon(stats)
 
-	for _, metric := range commonInformationOrder {
+	for _, metric := range CommonInformationOrder(stats) {
 		_, _ = fmt.Fprintf(sb, "|%s |%s%s", metric, common[metric], util.LineSeparator())
 	}
 
@@ -46,13 +55,23 @@ func AddADOCCommonInformation(sb *strings.Builder, stats *analyzer.Statistics) {
 func AddADOCResources(sb *strings.Builder, stats *analyzer.Statistics) {
 	_, _ = fmt.Fprintf(sb, "%s%s%s", adocHeader("Resources"), util.LineSeparator(), util.LineSeparator())
 
+	header := ResourcesInformationADOCHeader
+
+	if note := ApproximateNote(&stats.ResourcesCount.KeyCount, "resources"); note != "" {
+		_, _ = fmt.Fprintf(sb, "%s%s%s", note, util.LineSeparator(), util.LineSeparator())
+		header = ResourcesApproximateADOCHeader
+	}
+
 	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
-	_, _ = fmt.Fprintf(sb, "%s%s", ResourcesInformationADOCHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", header, util.LineSeparator())
 
 	for _, resource := range stats.ResourcesCount.KeysOrder {
-		_, _ = fmt.Fprintf(sb, "|`%s` |%s%s", resource,
-			FormatWithUnderscores(fmt.Sprintf("%d", stats.ResourcesCount.Values[resource])),
-			util.LineSeparator())
+		cnt := FormatWithUnderscores(fmt.Sprintf("%d", stats.ResourcesCount.Values[resource]))
+		if stats.ResourcesCount.Approximate() {
+			cnt = FormatApproximateCount(stats.ResourcesCount.Values[resource], stats.ResourcesCount.Error(resource))
+		}
+
+		_, _ = fmt.Fprintf(sb, "|`%s` |%s%s", resource, cnt, util.LineSeparator())
 	}
 
 	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
@@ -79,11 +98,214 @@ func AddADOCRequestCodes(sb *strings.Builder, stats *analyzer.Statistics) {
 func AddADOCIPCount(sb *strings.Builder, stats *analyzer.Statistics) {
 	_, _ = fmt.Fprintf(sb, "%s%s%s", adocHeader("IP Count"), util.LineSeparator(), util.LineSeparator())
 
+	header := IPCountADOCHeader
+
+	if note := ApproximateNote(&stats.IPCount.KeyCount, "IPs"); note != "" {
+		_, _ = fmt.Fprintf(sb, "%s%s%s", note, util.LineSeparator(), util.LineSeparator())
+		header = IPCountApproximateADOCHeader
+	}
+
 	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
-	_, _ = fmt.Fprintf(sb, "%s%s", IPCountADOCHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", header, util.LineSeparator())
 
 	for _, ip := range stats.IPCount.KeysOrder {
-		_, _ = fmt.Fprintf(sb, "| %s | %d%s", ip, stats.IPCount.Values[ip], util.LineSeparator())
+		cnt := fmt.Sprintf("%d", stats.IPCount.Values[ip])
+		if stats.IPCount.Approximate() {
+			cnt = FormatApproximateCount(stats.IPCount.Values[ip], stats.IPCount.Error(ip))
+		}
+
+		_, _ = fmt.Fprintf(sb, "| %s | %s%s", ip, cnt, util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+// AddADOCGroupBy добавляет таблицу количества запросов по значениям поля --group-by в формате AsciiDoc.
+func AddADOCGroupBy(sb *strings.Builder, stats *analyzer.Statistics) {
+	if stats.GroupBy.Field == "" {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Group by "+stats.GroupBy.Field),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, GroupByADOCHeader+"%s", stats.GroupBy.Field, util.LineSeparator())
+
+	for _, value := range stats.GroupBy.KeysOrder {
+		_, _ = fmt.Fprintf(sb, "|`%s` |%s%s", value,
+			FormatWithUnderscores(fmt.Sprintf("%d", stats.GroupBy.Values[value])),
+			util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+// AddADOCParseErrors добавляет таблицу пропущенных строчек по файлам и причинам ошибок в формате AsciiDoc.
+func AddADOCParseErrors(sb *strings.Builder, stats *analyzer.Statistics) {
+	rows := ParseErrorRows(stats)
+	if len(rows) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Parse errors"),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", ParseErrorsADOCHeader, util.LineSeparator())
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(sb, "|`%s` |%s |%s%s", row.File, row.Reason,
+			FormatWithUnderscores(fmt.Sprintf("%d", row.Count)), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+// AddADOCStatus добавляет доли ошибок, таблицу количества запросов по классам кодов ответа
+// и таблицы ресурсов с наибольшим количеством ошибок 5xx и 4xx в формате AsciiDoc.
+func AddADOCStatus(sb *strings.Builder, stats *analyzer.Statistics) {
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Status classes"),
+		util.LineSeparator(), util.LineSeparator())
+
+	for _, line := range StatusSummary(&stats.Status) {
+		_, _ = fmt.Fprintf(sb, "* %s%s", line, util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s", util.LineSeparator(), ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", StatusClassesADOCHeader, util.LineSeparator())
+
+	for _, class := range stats.Status.ClassesOrder {
+		cnt := stats.Status.Classes[class]
+		_, _ = fmt.Fprintf(sb, "|%s |%s |%s%s", class, FormatWithUnderscores(fmt.Sprintf("%d", cnt)),
+			FormatShare(cnt, int(stats.TotalRequestsNumber.Int64())), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+
+	addADOCErrorResources(sb, &stats.Status.ServerErrors, "5xx")
+	addADOCErrorResources(sb, &stats.Status.ClientErrors, "4xx")
+}
+
+func addADOCErrorResources(sb *strings.Builder, count *analyzer.KeyCount, class string) {
+	if len(count.KeysOrder) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Top resources by "+class),
+		util.LineSeparator(), util.LineSeparator())
+
+	if note := ApproximateNote(count, "resources with "+class); note != "" {
+		_, _ = fmt.Fprintf(sb, "%s%s%s", note, util.LineSeparator(), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "|%s%s", strings.Join(ErrorResourceColumns(count, class), " |"), util.LineSeparator())
+
+	for _, row := range ErrorResourceRows(count) {
+		_, _ = fmt.Fprintf(sb, "|%s%s", strings.Join(row, " |"), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+// AddADOCMethods добавляет таблицы запросов по методам и по версиям протокола в формате AsciiDoc.
+// Необычные методы помечаются в таблице и перечисляются над ней.
+func AddADOCMethods(sb *strings.Builder, stats *analyzer.Statistics) {
+	total := int(stats.TotalRequestsNumber.Int64())
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Methods"),
+		util.LineSeparator(), util.LineSeparator())
+
+	if summary := UnusualMethodsSummary(&stats.Methods, total); summary != "" {
+		_, _ = fmt.Fprintf(sb, "* %s%s%s", summary, util.LineSeparator(), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", MethodsADOCHeader, util.LineSeparator())
+
+	for _, method := range stats.Methods.KeysOrder {
+		_, _ = fmt.Fprintf(sb, "|%s%s", strings.Join(MethodCells(method, stats.Methods.Values[method], total), " |"),
+			util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Protocols"),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", ProtocolsADOCHeader, util.LineSeparator())
+
+	for _, protocol := range stats.Protocols.KeysOrder {
+		cnt := stats.Protocols.Values[protocol]
+		_, _ = fmt.Fprintf(sb, "|%s |%s |%s%s", protocol, FormatWithUnderscores(fmt.Sprintf("%d", cnt)),
+			FormatShare(cnt, total), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+// AddADOCLatency добавляет таблицы времени ответа по классам кодов ответа и по ресурсам,
+// а также гистограмму времени ответа в формате AsciiDoc. Если время ответа в логах не записано, ничего не добавляет.
+func AddADOCLatency(sb *strings.Builder, stats *analyzer.Statistics) {
+	if stats.Latency.Count() == 0 {
+		return
+	}
+
+	addADOCLatencyTable(sb, "Latency", LatencyColumns("Status", stats.PercentileRanks), LatencyClassRows(stats))
+	addADOCLatencyTable(sb, LatencyResourceTitle(stats), LatencyColumns("Resource", stats.PercentileRanks),
+		LatencyResourceRows(stats))
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Latency histogram"),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", LatencyHistogramADOCHeader, util.LineSeparator())
+
+	for bucket, cnt := range stats.Latency.Histogram {
+		_, _ = fmt.Fprintf(sb, "|%s |%s |%s%s", FormatLatencyBucket(bucket),
+			FormatWithUnderscores(fmt.Sprintf("%d", cnt)), FormatShare(cnt, stats.Latency.Count()),
+			util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+// AddADOCTraffic добавляет список самого загруженного и самого тихого интервала и таблицу трафика
+// по интервалам времени в формате AsciiDoc. Если --bucket не задан, ничего не добавляет.
+func AddADOCTraffic(sb *strings.Builder, stats *analyzer.Statistics) {
+	if len(stats.Traffic.Series) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader("Traffic over time"),
+		util.LineSeparator(), util.LineSeparator())
+
+	for _, line := range TrafficSummary(&stats.Traffic) {
+		_, _ = fmt.Fprintf(sb, "* %s%s", line, util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s", util.LineSeparator(), ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s%s", TrafficADOCHeader, util.LineSeparator())
+
+	for _, bucket := range stats.Traffic.Series {
+		_, _ = fmt.Fprintf(sb, "|%s%s", strings.Join(TrafficCells(bucket, stats.Traffic.Bucket), " |"),
+			util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+}
+
+func addADOCLatencyTable(sb *strings.Builder, title string, columns []string, rows []LatencyRow) {
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), adocHeader(title),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "|%s%s", strings.Join(columns, " |"), util.LineSeparator())
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(sb, "|%s%s", strings.Join(LatencyCells(row), " |"), util.LineSeparator())
 	}
 
 	_, _ = fmt.Fprintf(sb, "%s%s", ADOCTableSymbol, util.LineSeparator())
@@ -97,6 +319,12 @@ func ToADOC(statistics *analyzer.Statistics) []byte {
 	AddADOCResources(adocSb, statistics)
 	AddADOCRequestCodes(adocSb, statistics)
 	AddADOCIPCount(adocSb, statistics)
+	AddADOCGroupBy(adocSb, statistics)
+	AddADOCParseErrors(adocSb, statistics)
+	AddADOCStatus(adocSb, statistics)
+	AddADOCMethods(adocSb, statistics)
+	AddADOCLatency(adocSb, statistics)
+	AddADOCTraffic(adocSb, statistics)
 
 	return []byte(adocSb.String())
 }
@@ -2,20 +2,44 @@ package visual
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
 )
 
 var commonInformationOrder = []string{
 	"File(-s)",
+	"Log format(-s)",
 	"From data",
 	"To data",
 	"Requests count",
+	"Unique IPs",
+	"Unique clients (IP + User-Agent)",
+	"Unique resources",
 	"Minimum request size",
 	"Maximum request size",
 	"Average request size",
-	"Percentile",
+	percentileMetric,
+	"Parse errors",
+}
+
+// TopErrorResources это количество ресурсов в таблицах ресурсов с наибольшим количеством ошибок.
+const TopErrorResources = 10
+
+// TopLatencyResources это количество самых запрашиваемых ресурсов в таблице времени ответа по ресурсам.
+const TopLatencyResources = 10
+
+// percentileMetric заменяется в commonInformationOrder строками всех перцентилей размера запроса.
+const percentileMetric = "Percentile"
+
+// ParseErrorRow это строка таблицы пропущенных строчек: файл, причина ошибки и количество строчек.
+type ParseErrorRow struct {
+	File   string
+	Reason string
+	Count  int
 }
 
 // FormatWithUnderscores форматирует строку числа, добавляя символ `_` как разделитель тысяч.
@@ -53,6 +77,317 @@ func FormatFilenames(files []string) string {
 	return sb.String()
 }
 
+// FormatLogFormats форматирует форматы логов файлов files для отображения.
+// Если у всех файлов один формат, он выводится один раз.
+// FormatLogFormats([]string{"1", "2"}, map[string]string{"1": "json", "2": "common"}) = "`1`: `json` `2`: `common` ".
+func FormatLogFormats(files []string, formats map[string]string) string {
+	sb := strings.Builder{}
+
+	for _, file := range files {
+		if formats[file] != formats[files[0]] {
+			for _, file := range files {
+				sb.WriteString(fmt.Sprintf("`%s`: `%s` ", file, formats[file]))
+			}
+
+			return sb.String()
+		}
+	}
+
+	if len(files) != 0 {
+		sb.WriteString(fmt.Sprintf("`%s` ", formats[files[0]]))
+	}
+
+	return sb.String()
+}
+
+// FormatParseErrors форматирует количество пропущенных строчек и их долю среди всех прочитанных строчек.
+// FormatParseErrors(1, 10000) = "1 (0.01%)".
+func FormatParseErrors(errors, lines int) string {
+	return fmt.Sprintf("%s (%s)", FormatWithUnderscores(fmt.Sprintf("%d", errors)), FormatShare(errors, lines))
+}
+
+// ParseErrorRows возвращает строки таблицы пропущенных строчек в порядке файлов в отчете,
+// а для каждого файла — по убыванию количества строчек.
+func ParseErrorRows(stats *analyzer.Statistics) []ParseErrorRow {
+	rows := make([]ParseErrorRow, 0)
+
+	for _, file := range stats.Files {
+		start := len(rows)
+
+		for reason, count := range stats.ParseErrors.Values[file] {
+			rows = append(rows, ParseErrorRow{File: file, Reason: reason, Count: count})
+		}
+
+		fileRows := rows[start:]
+
+		sort.Slice(fileRows, func(i, j int) bool {
+			if fileRows[i].Count != fileRows[j].Count {
+				return fileRows[i].Count > fileRows[j].Count
+			}
+
+			return fileRows[i].Reason < fileRows[j].Reason
+		})
+	}
+
+	return rows
+}
+
+// FormatApproximateCount форматирует приближенное количество запросов cnt с наибольшей погрешностью errorBound.
+// FormatApproximateCount(1234, 56) = "≈1_234 ±56".
+func FormatApproximateCount(cnt, errorBound int) string {
+	return fmt.Sprintf("≈%s ±%s", FormatWithUnderscores(fmt.Sprintf("%d", cnt)),
+		FormatWithUnderscores(fmt.Sprintf("%d", errorBound)))
+}
+
+// FormatEstimate форматирует приближенное количество различных значений.
+// FormatEstimate(1234) = "≈1_234".
+func FormatEstimate(cnt int) string {
+	return "≈" + FormatWithUnderscores(fmt.Sprintf("%d", cnt))
+}
+
+// ApproximateNote возвращает пояснение к таблице с приближенными количествами запросов по ключам keys
+// (например, "resources") или пустую строку, если количества точные.
+func ApproximateNote(count *analyzer.KeyCount, keys string) string {
+	if !count.Approximate() {
+		return ""
+	}
+
+	return fmt.Sprintf("_Approximate counts: only the %d most frequent %s are tracked (Space-Saving), "+
+		"each count exceeds the exact one by at most the value after ±, any other one occurred at most %s times._",
+		count.TopK.Capacity, keys, FormatWithUnderscores(fmt.Sprintf("%d", count.ErrorBound)))
+}
+
+// LatencyRow это строка таблицы времени ответа: имя группы запросов и время ответа в ней.
+type LatencyRow struct {
+	Name    string
+	Latency *analyzer.Latency
+}
+
+// FormatLatency форматирует время ответа в секундах для отображения.
+// FormatLatency(0.0123456) = "12.346ms".
+func FormatLatency(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond).String()
+}
+
+// FormatLatencyBucket форматирует интервал гистограммы времени ответа с номером bucket (см. analyzer.LatencyBuckets).
+// FormatLatencyBucket(0) = "<= 5ms".
+func FormatLatencyBucket(bucket int) string {
+	if bucket < len(analyzer.LatencyBuckets) {
+		return "<= " + FormatLatency(analyzer.LatencyBuckets[bucket])
+	}
+
+	return "> " + FormatLatency(analyzer.LatencyBuckets[len(analyzer.LatencyBuckets)-1])
+}
+
+// FormatShare форматирует долю cnt от total в процентах.
+// FormatShare(1, 8) = "12.50%".
+func FormatShare(cnt, total int) string {
+	share := 0.0
+	if total != 0 {
+		share = float64(cnt) * 100 / float64(total)
+	}
+
+	return FormatRate(share)
+}
+
+// FormatRate форматирует долю в процентах.
+// FormatRate(12.5) = "12.50%".
+func FormatRate(rate float64) string {
+	return fmt.Sprintf("%.2f%%", rate)
+}
+
+// StatusSummary возвращает строки с долями ошибок среди всех запросов.
+func StatusSummary(status *analyzer.StatusStats) []string {
+	return []string{
+		"Error rate: " + FormatRate(status.ErrorRate),
+		"Client errors (4xx): " + FormatRate(status.ClientErrorRate),
+		"Server errors (5xx): " + FormatRate(status.ServerErrorRate),
+	}
+}
+
+// MethodCells возвращает значения строки таблицы методов: метод (необычный помечается), количество запросов,
+// их долю среди total запросов, размер ответов и долю ошибок.
+func MethodCells(method string, stats *analyzer.Method, total int) []string {
+	if stats.Unusual {
+		method += " (unusual)"
+	}
+
+	return []string{
+		method,
+		FormatWithUnderscores(fmt.Sprintf("%d", stats.Requests)),
+		FormatShare(stats.Requests, total),
+		FormatWithUnderscores(fmt.Sprintf("%d", stats.Bytes)) + "b",
+		FormatRate(stats.ErrorRate()),
+	}
+}
+
+// UnusualMethodsSummary возвращает строку о необычных методах и количестве запросов ими
+// или пустую строку, если таких запросов не было.
+func UnusualMethodsSummary(methods *analyzer.MethodCount, total int) string {
+	unusual, requests := methods.Unusual()
+	if len(unusual) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("Unusual methods, often a sign of scanning: %s in %s requests (%s)", strings.Join(unusual, ", "),
+		FormatWithUnderscores(fmt.Sprintf("%d", requests)), FormatShare(requests, total))
+}
+
+// ErrorResourceRows возвращает строки таблицы ресурсов с наибольшим количеством ошибок count:
+// ресурс и количество ошибок, в приближенном режиме — с погрешностью.
+func ErrorResourceRows(count *analyzer.KeyCount) [][]string {
+	keys := count.KeysOrder[:min(len(count.KeysOrder), TopErrorResources)]
+	rows := make([][]string, 0, len(keys))
+
+	for _, resource := range keys {
+		cnt := FormatWithUnderscores(fmt.Sprintf("%d", count.Values[resource]))
+		if count.Approximate() {
+			cnt = FormatApproximateCount(count.Values[resource], count.Error(resource))
+		}
+
+		rows = append(rows, []string{"`" + resource + "`", cnt})
+	}
+
+	return rows
+}
+
+// ErrorResourceColumns возвращает заголовки столбцов таблицы ресурсов с наибольшим количеством ошибок класса class.
+func ErrorResourceColumns(count *analyzer.KeyCount, class string) []string {
+	if count.Approximate() {
+		return []string{"Resource", class + " (approx.)"}
+	}
+
+	return []string{"Resource", class}
+}
+
+// FormatBucket форматирует начало интервала трафика длины bucket в его часовом поясе:
+// суточные интервалы выводятся датой, остальные — датой и временем.
+// FormatBucket(time.Date(2024, 10, 10, 13, 5, 0, 0, time.UTC), 5*time.Minute) = "2024-10-10 13:05".
+func FormatBucket(start time.Time, bucket time.Duration) string {
+	if bucket == 24*time.Hour {
+		return start.Format(time.DateOnly)
+	}
+
+	return start.Format("2006-01-02 15:04")
+}
+
+// TrafficCells возвращает значения строки таблицы трафика: начало интервала, количество запросов,
+// размер ответов и количество ошибок.
+func TrafficCells(bucket analyzer.TrafficBucket, length time.Duration) []string {
+	return []string{
+		FormatBucket(bucket.Start, length),
+		FormatWithUnderscores(fmt.Sprintf("%d", bucket.Requests)),
+		FormatWithUnderscores(fmt.Sprintf("%d", bucket.Bytes)) + "b",
+		FormatWithUnderscores(fmt.Sprintf("%d", bucket.Errors)),
+	}
+}
+
+// TrafficSummary возвращает строки о самом загруженном и самом тихом интервале трафика.
+func TrafficSummary(traffic *analyzer.Traffic) []string {
+	peak, quietest := traffic.Series[traffic.Peak], traffic.Series[traffic.Quietest]
+
+	return []string{
+		fmt.Sprintf("Peak: %s (%s requests)", FormatBucket(peak.Start, traffic.Bucket),
+			FormatWithUnderscores(fmt.Sprintf("%d", peak.Requests))),
+		fmt.Sprintf("Quietest: %s (%s requests)", FormatBucket(quietest.Start, traffic.Bucket),
+			FormatWithUnderscores(fmt.Sprintf("%d", quietest.Requests))),
+	}
+}
+
+// LatencyColumns возвращает заголовки столбцов таблицы времени ответа: имя группы column,
+// количество запросов, минимальное, среднее и максимальное время ответа и перцентили ranks.
+func LatencyColumns(column string, ranks []float64) []string {
+	columns := []string{column, "Count", "Min", "Mean", "Max"}
+
+	for _, rank := range ranks {
+		columns = append(columns, FormatPercentileRank(rank))
+	}
+
+	return columns
+}
+
+// LatencyCells возвращает значения строки таблицы времени ответа в порядке LatencyColumns.
+func LatencyCells(row LatencyRow) []string {
+	cells := []string{
+		row.Name,
+		FormatWithUnderscores(fmt.Sprintf("%d", row.Latency.Distribution.Count)),
+		FormatLatency(row.Latency.Min),
+		FormatLatency(row.Latency.Mean),
+		FormatLatency(row.Latency.Max),
+	}
+
+	for _, percentile := range row.Latency.Percentiles {
+		cells = append(cells, FormatLatency(percentile))
+	}
+
+	return cells
+}
+
+// FormatPercentileRank форматирует ранг перцентиля для отображения.
+// FormatPercentileRank(99.9) = "p99.9".
+func FormatPercentileRank(rank float64) string {
+	return "p" + strconv.FormatFloat(rank, 'f', -1, 64)
+}
+
+// PercentileMetric возвращает имя строки общей информации с перцентилем размера запроса.
+// PercentileMetric(99.9) = "p99.9 request size".
+func PercentileMetric(rank float64) string {
+	return FormatPercentileRank(rank) + " request size"
+}
+
+// CommonInformationOrder возвращает порядок строк таблицы общей информации:
+// вместо одной строки перцентиля выводится по строке на каждый перцентиль из stats.PercentileRanks.
+func CommonInformationOrder(stats *analyzer.Statistics) []string {
+	order := make([]string, 0, len(commonInformationOrder)+len(stats.PercentileRanks))
+
+	for _, metric := range commonInformationOrder {
+		if metric != percentileMetric {
+			order = append(order, metric)
+			continue
+		}
+
+		for _, rank := range stats.PercentileRanks {
+			order = append(order, PercentileMetric(rank))
+		}
+	}
+
+	return order
+}
+
+// LatencyClassRows возвращает строки таблицы времени ответа: сначала все запросы, затем классы кодов ответа.
+func LatencyClassRows(stats *analyzer.Statistics) []LatencyRow {
+	rows := []LatencyRow{{Name: "All", Latency: &stats.Latency.Total}}
+
+	for _, class := range stats.Latency.StatusClassesOrder {
+		rows = append(rows, LatencyRow{Name: class, Latency: stats.Latency.StatusClasses[class]})
+	}
+
+	return rows
+}
+
+// LatencyResourceTitle возвращает заголовок таблицы времени ответа по ресурсам.
+// Если ресурсов больше TopLatencyResources, заголовок сообщает, что таблица неполная.
+func LatencyResourceTitle(stats *analyzer.Statistics) string {
+	if total := len(stats.Latency.ResourcesOrder); total > TopLatencyResources {
+		return fmt.Sprintf("Latency by resource (top %d of %s resources)", TopLatencyResources,
+			FormatWithUnderscores(fmt.Sprintf("%d", total)))
+	}
+
+	return "Latency by resource"
+}
+
+// LatencyResourceRows возвращает строки таблицы времени ответа по TopLatencyResources самым запрашиваемым ресурсам.
+func LatencyResourceRows(stats *analyzer.Statistics) []LatencyRow {
+	resources := stats.Latency.ResourcesOrder[:min(len(stats.Latency.ResourcesOrder), TopLatencyResources)]
+	rows := make([]LatencyRow, 0, len(resources))
+
+	for _, resource := range resources {
+		rows = append(rows, LatencyRow{Name: "`" + resource + "`", Latency: stats.Latency.Resources[resource]})
+	}
+
+	return rows
+}
+
 // Reverse разворачивает строку.
 func Reverse(s string) string {
 	runes := []rune(s)
@@ -65,14 +400,24 @@ func Reverse(s string) string {
 
 // OutputToCommon создаёт мапу значений для общей информации о статистике и форматирует данные.
 func OutputToCommon(data *analyzer.Statistics) CommonInformation {
-	return CommonInformation{
-		"File(-s)":             FormatFilenames(data.Files),
-		"From data":            data.From,
-		"To data":              data.To,
-		"Requests count":       FormatWithUnderscores(data.TotalRequestsNumber.String()),
-		"Minimum request size": FormatWithUnderscores(fmt.Sprintf("%d", data.MinSizeRequest)) + "b",
-		"Maximum request size": FormatWithUnderscores(fmt.Sprintf("%d", data.MaxSizeRequest)) + "b",
-		"Average request size": FormatWithUnderscores(data.AverageRequestNumber.String()) + "b",
-		"Percentile":           FormatWithUnderscores(fmt.Sprintf("%d", data.Percentile)) + "b",
+	common := CommonInformation{
+		"File(-s)":                         FormatFilenames(data.Files),
+		"Log format(-s)":                   FormatLogFormats(data.Files, data.Formats),
+		"From data":                        data.From,
+		"To data":                          data.To,
+		"Requests count":                   FormatWithUnderscores(data.TotalRequestsNumber.String()),
+		"Unique IPs":                       FormatEstimate(data.Cardinality.UniqueIPs),
+		"Unique clients (IP + User-Agent)": FormatEstimate(data.Cardinality.UniqueClients),
+		"Unique resources":                 FormatEstimate(data.Cardinality.UniqueResources),
+		"Minimum request size":             FormatWithUnderscores(fmt.Sprintf("%d", data.MinSizeRequest)) + "b",
+		"Maximum request size":             FormatWithUnderscores(fmt.Sprintf("%d", data.MaxSizeRequest)) + "b",
+		"Average request size":             FormatWithUnderscores(data.AverageRequestNumber.String()) + "b",
+		"Parse errors":                     FormatParseErrors(data.ParseErrorsCount(), data.LinesRead),
 	}
+
+	for i, rank := range data.PercentileRanks {
+		common[PercentileMetric(rank)] = FormatWithUnderscores(fmt.Sprintf("%d", data.Percentiles[i])) + "b"
+	}
+
+	return common
 }
@@ -15,8 +15,17 @@ type CommonInformation = map[string]string
 const (
 	CommonInformationMarkdownHeader = "| Metrics | Value |"
 	ResourcesInformationHeader      = "| Resource | Count |"
+	ResourcesApproximateHeader      = "| Resource | Count (approx.) |"
 	RequestCodesHeader              = "| Code | Name | Count |"
 	IPCountHeader                   = "| IP | Count |"
+	IPCountApproximateHeader        = "| IP | Count (approx.) |"
+	GroupByHeader                   = "| %s | Count |"
+	ParseErrorsHeader               = "| File | Reason | Count |"
+	LatencyHistogramHeader          = "| Latency | Count | Share |"
+	TrafficHeader                   = "| Time | Requests | Bytes | Errors |"
+	StatusClassesHeader             = "| Class | Count | Share |"
+	MethodsHeader                   = "| Method | Count | Share | Bytes | Error rate |"
+	ProtocolsHeader                 = "| Protocol | Count | Share |"
 	MarkdownHeader                  = "####"
 )
 
@@ -41,7 +50,7 @@ func AddMarkdownCommonInformation(sb *strings.Builder, stats *analyzer.Statistic
 
 	common := OutputToCommon(stats)
 
-	for _, metric := range commonInformationOrder {
+	for _, metric := range CommonInformationOrder(stats) {
 		_, _ = fmt.Fprintf(sb, "| %s | %s |%s", metric, common[metric], util.LineSeparator())
 	}
 
@@ -52,13 +61,23 @@ func AddMarkdownCommonInformation(sb *strings.Builder, stats *analyzer.Statistic
 func AddMarkdownResources(sb *strings.Builder, stats *analyzer.Statistics) {
 	_, _ = fmt.Fprintf(sb, "%s%s%s", markdownHeader("Resources"), util.LineSeparator(), util.LineSeparator())
 
-	_, _ = fmt.Fprintf(sb, "%s%s", ResourcesInformationHeader, util.LineSeparator())
+	header := ResourcesInformationHeader
+
+	if note := ApproximateNote(&stats.ResourcesCount.KeyCount, "resources"); note != "" {
+		_, _ = fmt.Fprintf(sb, "%s%s%s", note, util.LineSeparator(), util.LineSeparator())
+		header = ResourcesApproximateHeader
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", header, util.LineSeparator())
 	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(2))
 
 	for _, resource := range stats.ResourcesCount.KeysOrder {
-		_, _ = fmt.Fprintf(sb, "| `%s` | %s |%s", resource,
-			FormatWithUnderscores(fmt.Sprintf("%d", stats.ResourcesCount.Values[resource])),
-			util.LineSeparator())
+		cnt := FormatWithUnderscores(fmt.Sprintf("%d", stats.ResourcesCount.Values[resource]))
+		if stats.ResourcesCount.Approximate() {
+			cnt = FormatApproximateCount(stats.ResourcesCount.Values[resource], stats.ResourcesCount.Error(resource))
+		}
+
+		_, _ = fmt.Fprintf(sb, "| `%s` | %s |%s", resource, cnt, util.LineSeparator())
 	}
 
 	_, _ = fmt.Fprintf(sb, "%s", util.LineSeparator())
@@ -84,11 +103,198 @@ func AddMarkdownRequestCodes(sb *strings.Builder, stats *analyzer.Statistics) {
 func AddMarkdownIPCount(sb *strings.Builder, stats *analyzer.Statistics) {
 	_, _ = fmt.Fprintf(sb, "%s%s%s", markdownHeader("IP count"), util.LineSeparator(), util.LineSeparator())
 
-	_, _ = fmt.Fprintf(sb, "%s%s", IPCountHeader, util.LineSeparator())
+	header := IPCountHeader
+
+	if note := ApproximateNote(&stats.IPCount.KeyCount, "IPs"); note != "" {
+		_, _ = fmt.Fprintf(sb, "%s%s%s", note, util.LineSeparator(), util.LineSeparator())
+		header = IPCountApproximateHeader
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", header, util.LineSeparator())
 	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(2))
 
 	for _, ip := range stats.IPCount.KeysOrder {
-		_, _ = fmt.Fprintf(sb, "| %s | %d |%s", ip, stats.IPCount.Values[ip], util.LineSeparator())
+		cnt := fmt.Sprintf("%d", stats.IPCount.Values[ip])
+		if stats.IPCount.Approximate() {
+			cnt = FormatApproximateCount(stats.IPCount.Values[ip], stats.IPCount.Error(ip))
+		}
+
+		_, _ = fmt.Fprintf(sb, "| %s | %s |%s", ip, cnt, util.LineSeparator())
+	}
+}
+
+// AddMarkdownGroupBy добавляет таблицу количества запросов по значениям поля --group-by в формате markdown.
+func AddMarkdownGroupBy(sb *strings.Builder, stats *analyzer.Statistics) {
+	if stats.GroupBy.Field == "" {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Group by "+stats.GroupBy.Field),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, GroupByHeader+"%s", stats.GroupBy.Field, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(2))
+
+	for _, value := range stats.GroupBy.KeysOrder {
+		_, _ = fmt.Fprintf(sb, "| `%s` | %s |%s", value,
+			FormatWithUnderscores(fmt.Sprintf("%d", stats.GroupBy.Values[value])),
+			util.LineSeparator())
+	}
+}
+
+// AddMarkdownParseErrors добавляет таблицу пропущенных строчек по файлам и причинам ошибок в формате markdown.
+func AddMarkdownParseErrors(sb *strings.Builder, stats *analyzer.Statistics) {
+	rows := ParseErrorRows(stats)
+	if len(rows) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Parse errors"),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ParseErrorsHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(3))
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(sb, "| `%s` | %s | %s |%s", row.File, row.Reason,
+			FormatWithUnderscores(fmt.Sprintf("%d", row.Count)), util.LineSeparator())
+	}
+}
+
+// AddMarkdownStatus добавляет доли ошибок, таблицу количества запросов по классам кодов ответа
+// и таблицы ресурсов с наибольшим количеством ошибок 5xx и 4xx в формате markdown.
+func AddMarkdownStatus(sb *strings.Builder, stats *analyzer.Statistics) {
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Status classes"),
+		util.LineSeparator(), util.LineSeparator())
+
+	for _, line := range StatusSummary(&stats.Status) {
+		_, _ = fmt.Fprintf(sb, "* %s%s", line, util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s", util.LineSeparator(), StatusClassesHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(3))
+
+	for _, class := range stats.Status.ClassesOrder {
+		cnt := stats.Status.Classes[class]
+		_, _ = fmt.Fprintf(sb, "| %s | %s | %s |%s", class, FormatWithUnderscores(fmt.Sprintf("%d", cnt)),
+			FormatShare(cnt, int(stats.TotalRequestsNumber.Int64())), util.LineSeparator())
+	}
+
+	addMarkdownErrorResources(sb, &stats.Status.ServerErrors, "5xx")
+	addMarkdownErrorResources(sb, &stats.Status.ClientErrors, "4xx")
+}
+
+func addMarkdownErrorResources(sb *strings.Builder, count *analyzer.KeyCount, class string) {
+	if len(count.KeysOrder) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Top resources by "+class),
+		util.LineSeparator(), util.LineSeparator())
+
+	if note := ApproximateNote(count, "resources with "+class); note != "" {
+		_, _ = fmt.Fprintf(sb, "%s%s%s", note, util.LineSeparator(), util.LineSeparator())
+	}
+
+	columns := ErrorResourceColumns(count, class)
+
+	_, _ = fmt.Fprintf(sb, "| %s |%s", strings.Join(columns, " | "), util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(len(columns)))
+
+	for _, row := range ErrorResourceRows(count) {
+		_, _ = fmt.Fprintf(sb, "| %s |%s", strings.Join(row, " | "), util.LineSeparator())
+	}
+}
+
+// AddMarkdownMethods добавляет таблицы запросов по методам и по версиям протокола в формате markdown.
+// Необычные методы помечаются в таблице и перечисляются над ней.
+func AddMarkdownMethods(sb *strings.Builder, stats *analyzer.Statistics) {
+	total := int(stats.TotalRequestsNumber.Int64())
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Methods"),
+		util.LineSeparator(), util.LineSeparator())
+
+	if summary := UnusualMethodsSummary(&stats.Methods, total); summary != "" {
+		_, _ = fmt.Fprintf(sb, "* %s%s%s", summary, util.LineSeparator(), util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s", MethodsHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(5))
+
+	for _, method := range stats.Methods.KeysOrder {
+		_, _ = fmt.Fprintf(sb, "| %s |%s", strings.Join(MethodCells(method, stats.Methods.Values[method], total), " | "),
+			util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Protocols"),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", ProtocolsHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(3))
+
+	for _, protocol := range stats.Protocols.KeysOrder {
+		cnt := stats.Protocols.Values[protocol]
+		_, _ = fmt.Fprintf(sb, "| %s | %s | %s |%s", protocol, FormatWithUnderscores(fmt.Sprintf("%d", cnt)),
+			FormatShare(cnt, total), util.LineSeparator())
+	}
+}
+
+// AddMarkdownLatency добавляет таблицы времени ответа по классам кодов ответа и по ресурсам,
+// а также гистограмму времени ответа в формате markdown. Если время ответа в логах не записано, ничего не добавляет.
+func AddMarkdownLatency(sb *strings.Builder, stats *analyzer.Statistics) {
+	if stats.Latency.Count() == 0 {
+		return
+	}
+
+	addMarkdownLatencyTable(sb, "Latency", LatencyColumns("Status", stats.PercentileRanks), LatencyClassRows(stats))
+	addMarkdownLatencyTable(sb, LatencyResourceTitle(stats), LatencyColumns("Resource", stats.PercentileRanks),
+		LatencyResourceRows(stats))
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Latency histogram"),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "%s%s", LatencyHistogramHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(3))
+
+	for bucket, cnt := range stats.Latency.Histogram {
+		_, _ = fmt.Fprintf(sb, "| %s | %s | %s |%s", FormatLatencyBucket(bucket),
+			FormatWithUnderscores(fmt.Sprintf("%d", cnt)), FormatShare(cnt, stats.Latency.Count()),
+			util.LineSeparator())
+	}
+}
+
+func addMarkdownLatencyTable(sb *strings.Builder, title string, columns []string, rows []LatencyRow) {
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader(title),
+		util.LineSeparator(), util.LineSeparator())
+
+	_, _ = fmt.Fprintf(sb, "| %s |%s", strings.Join(columns, " | "), util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(len(columns)))
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(sb, "| %s |%s", strings.Join(LatencyCells(row), " | "), util.LineSeparator())
+	}
+}
+
+// AddMarkdownTraffic добавляет список самого загруженного и самого тихого интервала и таблицу трафика
+// по интервалам времени в формате markdown. Если --bucket не задан, ничего не добавляет.
+func AddMarkdownTraffic(sb *strings.Builder, stats *analyzer.Statistics) {
+	if len(stats.Traffic.Series) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s%s", util.LineSeparator(), markdownHeader("Traffic over time"),
+		util.LineSeparator(), util.LineSeparator())
+
+	for _, line := range TrafficSummary(&stats.Traffic) {
+		_, _ = fmt.Fprintf(sb, "* %s%s", line, util.LineSeparator())
+	}
+
+	_, _ = fmt.Fprintf(sb, "%s%s%s", util.LineSeparator(), TrafficHeader, util.LineSeparator())
+	_, _ = fmt.Fprintf(sb, "%s", horizontalBar(4))
+
+	for _, bucket := range stats.Traffic.Series {
+		_, _ = fmt.Fprintf(sb, "| %s |%s", strings.Join(TrafficCells(bucket, stats.Traffic.Bucket), " | "),
+			util.LineSeparator())
 	}
 }
 
@@ -100,6 +306,12 @@ func Markdown(data *analyzer.Statistics) []byte {
 	AddMarkdownResources(markdownSb, data)
 	AddMarkdownRequestCodes(markdownSb, data)
 	AddMarkdownIPCount(markdownSb, data)
+	AddMarkdownGroupBy(markdownSb, data)
+	AddMarkdownParseErrors(markdownSb, data)
+	AddMarkdownStatus(markdownSb, data)
+	AddMarkdownMethods(markdownSb, data)
+	AddMarkdownLatency(markdownSb, data)
+	AddMarkdownTraffic(markdownSb, data)
 
 	return []byte(markdownSb.String())
 }
@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+	"github.com/es-debug/backend-academy-2024-go-template/pkg/util"
+)
+
+const (
+	// EntrySeparator отделяет путь до архива от имени записи в нем: "bundle.zip!logs/access.log".
+	EntrySeparator = "!"
+)
+
+var (
+	zipExtensions = []string{".zip"}
+	tarExtensions = []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.zst", ".tzst"}
+)
+
+// Reader реализация интерфейса input.LogReader (для чтения одной записи архива).
+// Сжатые записи (gzip, bzip2, zstd) распаковываются на лету.
+type Reader struct {
+	reader *bufio.Reader   // reader для буфферизированного чтения.
+	closer io.Closer       // closer закрывает распаковщик записи, сам архив остается открытым.
+	parser impl.LineParser // parser разбирает и фильтрует строчки лога.
+}
+
+func newReader(entry io.ReadCloser, name string, parser impl.LineParser) (*Reader, error) {
+	source, err := impl.Decompress(entry, name)
+	if err != nil {
+		_ = entry.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReaderSize(source, impl.BufferSize)
+
+	return &Reader{
+		reader: reader,
+		closer: source,
+		parser: parser.Detect(reader),
+	}, nil
+}
+
+func (r *Reader) Read() (*log.Record, error) {
+	return impl.ReadWithPattern(r.reader, r.parser)
+}
+
+// Format возвращает имя формата читаемого лога.
+func (r *Reader) Format() string {
+	return r.parser.Format
+}
+
+func (r *Reader) Close() error {
+	return r.closer.Close()
+}
+
+// IsArchive проверяет по расширению, является ли path zip или tar архивом (в том числе сжатым).
+func IsArchive(path string) bool {
+	return hasExtension(path, zipExtensions) || hasExtension(path, tarExtensions)
+}
+
+// Walk открывает архив path и вызывает visit для каждого файла внутри него в порядке их следования.
+// Если entryPattern не пустой, обрабатываются только файлы, подходящие под него (см. util.MatchPath).
+// Reader, переданный в visit, можно использовать только до возврата из visit.
+func Walk(path, entryPattern string, parser impl.LineParser, visit func(name string, reader *Reader) error) error {
+	if hasExtension(path, zipExtensions) {
+		return walkZip(path, entryPattern, parser, visit)
+	}
+
+	return walkTar(path, entryPattern, parser, visit)
+}
+
+func walkZip(path, entryPattern string, parser impl.LineParser, visit func(name string, reader *Reader) error) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		if !entry.Mode().IsRegular() || !matchEntry(entryPattern, entry.Name) {
+			continue
+		}
+
+		content, err := entry.Open()
+		if err != nil {
+			return err
+		}
+
+		if err := visitEntry(content, entry.Name, parser, visit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkTar(path, entryPattern string, parser impl.LineParser, visit func(name string, reader *Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	source, err := impl.Decompress(file, path)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	defer source.Close()
+
+	archive := tar.NewReader(source)
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg || !matchEntry(entryPattern, header.Name) {
+			continue
+		}
+
+		if err := visitEntry(io.NopCloser(archive), header.Name, parser, visit); err != nil {
+			return err
+		}
+	}
+}
+
+func visitEntry(content io.ReadCloser, name string, parser impl.LineParser, visit func(name string, reader *Reader) error) error {
+	reader, err := newReader(content, name, parser)
+	if err != nil {
+		return err
+	}
+
+	err = visit(name, reader)
+
+	return errors.Join(err, reader.Close())
+}
+
+func matchEntry(entryPattern, name string) bool {
+	return entryPattern == "" || util.MatchPath(entryPattern, name)
+}
+
+func hasExtension(path string, extensions []string) bool {
+	lowerPath := strings.ToLower(path)
+
+	for _, extension := range extensions {
+		if strings.HasSuffix(lowerPath, extension) {
+			return true
+		}
+	}
+
+	return false
+}
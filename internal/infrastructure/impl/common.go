@@ -2,17 +2,100 @@ package impl
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"io"
 
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
 )
 
-func processLastLog(line, field, pattern string) (*log.Record, error) {
-	lineStr, match, err2 := log.New(line, field, pattern)
+const (
+	// BufferSize это размер буфера чтения логов. Из него же берутся строчки для определения формата.
+	BufferSize = 64 << 10
+	// detectSampleLines это максимальное количество первых строчек лога, по которым определяется формат.
+	detectSampleLines = 100
+)
+
+// LineParser объединяет формат лога и параметры фильтрации,
+// общие для всех реализаций input.LogReader.
+type LineParser struct {
+	Parser  log.Parser // Parser разбирает строчки лога, nil означает формат nginx "combined".
+	Field   string     // Field и Pattern нужны в случае фильтрации части лога по значению.
+	Pattern string
+	Format  string // Format это имя формата лога для отчета.
+
+	auto *log.AutoParser // auto сохраняется после Detect, чтобы формат можно было определить заново.
+}
+
+// Detect определяет формат лога по первым строчкам reader, если Parser это *log.AutoParser,
+// и возвращает LineParser с выбранным форматом. Detect ждет, пока в буфере reader наберется detectSampleLines
+// строчек, поток закончится или буфер заполнится; последняя строчка без перевода строки учитывается, только
+// если на ней поток закончился. Остальные LineParser возвращаются без изменений.
+func (p LineParser) Detect(reader *bufio.Reader) LineParser {
+	auto := p.auto
+	if auto == nil {
+		auto, _ = p.Parser.(*log.AutoParser)
+	}
+
+	if auto == nil {
+		return p
+	}
+
+	sample, err := peekLines(reader, detectSampleLines)
+
+	lines := make([]string, 0, detectSampleLines)
+
+	for len(lines) < detectSampleLines && len(sample) != 0 {
+		line, rest, found := bytes.Cut(sample, []byte{'\n'})
+		if !found && !errors.Is(err, io.EOF) {
+			break
+		}
+
+		if len(bytes.TrimSpace(line)) != 0 {
+			lines = append(lines, string(line))
+		}
+
+		sample = rest
+	}
+
+	detected := auto.Detect(lines)
+
+	p.auto, p.Parser, p.Format = auto, detected.Parser, detected.Name
+
+	return p
+}
+
+// peekLines возвращает начало буфера reader, в котором есть count переводов строки, не продвигая reader.
+// Если поток закончился или буфер заполнился раньше, возвращает все, что есть в буфере, и ошибку Peek.
+func peekLines(reader *bufio.Reader, count int) ([]byte, error) {
+	var (
+		sample  []byte
+		err     error
+		scanned int
+		found   int
+	)
+
+	for found < count && err == nil {
+		sample, err = reader.Peek(len(sample) + 1)
+		found += bytes.Count(sample[scanned:], []byte{'\n'})
+		scanned = len(sample)
+	}
+
+	return sample, err
+}
+
+// ParseLine разбирает одну строчку лога и возвращает *log.Record,
+// nil, если строчка не проходит фильтрацию, или *log.ParseError, если строчку не удалось разобрать.
+func (p LineParser) ParseLine(line string) (*log.Record, error) {
+	parser := p.Parser
+	if parser == nil {
+		parser = log.Combined
+	}
+
+	lineStr, match, err2 := parser.Parse(line, p.Field, p.Pattern)
 
 	if err2 != nil {
-		return nil, err2
+		return nil, &log.ParseError{Text: line, Err: err2}
 	}
 
 	if !match {
@@ -25,16 +108,30 @@ func processLastLog(line, field, pattern string) (*log.Record, error) {
 // ReadWithPattern выделяет общую часть для реализаций input.LogReader
 // Считывает строчку через reader, и возвращает *log.Record, если все успешно,
 // Или error, если что-то пошло не так.
-func ReadWithPattern(reader *bufio.Reader, field, pattern string) (*log.Record, error) {
+func ReadWithPattern(reader *bufio.Reader, parser LineParser) (*log.Record, error) {
 	line, err := reader.ReadString('\n')
 
 	if err != nil {
 		if errors.Is(err, io.EOF) && line != "" {
-			return processLastLog(line, field, pattern)
+			return parser.ParseLine(line)
 		}
 
 		return nil, err
 	}
 
-	return processLastLog(line, field, pattern)
+	return parser.ParseLine(line)
+}
+
+// ReadCompleteLine работает так же, как ReadWithPattern, но обрабатывает только строчки,
+// которые заканчиваются переводом строки, и прибавляет их длину к offset.
+// Недописанная последняя строчка не обрабатывается: ее дочитает следующий запуск.
+func ReadCompleteLine(reader *bufio.Reader, parser LineParser, offset *int64) (*log.Record, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	*offset += int64(len(line))
+
+	return parser.ParseLine(line)
 }
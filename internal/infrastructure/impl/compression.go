@@ -0,0 +1,120 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression обозначает алгоритм сжатия входного потока.
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	Gzip
+	Bzip2
+	Zstd
+)
+
+const (
+	magicBytesLength = 4
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	extensionToCompression = map[string]Compression{
+		".gz":   Gzip,
+		".gzip": Gzip,
+		".bz2":  Bzip2,
+		".zst":  Zstd,
+		".zstd": Zstd,
+	}
+)
+
+// DetectCompression определяет алгоритм сжатия по первым байтам потока (magic bytes),
+// а если они не подходят ни под один из известных форматов, то по расширению name.
+func DetectCompression(header []byte, name string) Compression {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(header, bzip2Magic):
+		return Bzip2
+	}
+
+	if compression, ok := extensionToCompression[strings.ToLower(filepath.Ext(name))]; ok {
+		return compression
+	}
+
+	return NoCompression
+}
+
+// decompressor закрывает распаковщик вместе с исходным потоком.
+type decompressor struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decompressor) Close() error {
+	var errs []error
+
+	for _, closer := range d.closers {
+		errs = append(errs, closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Decompress оборачивает source в потоковый распаковщик gzip, bzip2 или zstd,
+// если поток сжат, и возвращает его без изменений в обратном случае.
+// Закрытие результата закрывает и source.
+func Decompress(source io.ReadCloser, name string) (io.ReadCloser, error) {
+	reader, _, err := DecompressDetected(source, name)
+
+	return reader, err
+}
+
+// DecompressDetected работает так же, как Decompress, и дополнительно возвращает обнаруженный алгоритм сжатия.
+func DecompressDetected(source io.ReadCloser, name string) (io.ReadCloser, Compression, error) {
+	buffered := bufio.NewReader(source)
+
+	header, err := buffered.Peek(magicBytesLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, NoCompression, err
+	}
+
+	compression := DetectCompression(header, name)
+
+	switch compression {
+	case Gzip:
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, compression, err
+		}
+
+		return &decompressor{Reader: gzipReader, closers: []io.Closer{gzipReader, source}}, compression, nil
+	case Bzip2:
+		return &decompressor{Reader: bzip2.NewReader(buffered), closers: []io.Closer{source}}, compression, nil
+	case Zstd:
+		zstdReader, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, compression, err
+		}
+
+		return &decompressor{Reader: zstdReader, closers: []io.Closer{zstdReader.IOReadCloser(), source}},
+			compression, nil
+	default:
+		return &decompressor{Reader: buffered, closers: []io.Closer{source}}, compression, nil
+	}
+}
@@ -0,0 +1,159 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+)
+
+// Chunk это часть файла [Start, End), границы которой совпадают с началами строчек.
+type Chunk struct {
+	Start int64
+	End   int64
+}
+
+// Split делит файл на count частей примерно одинакового размера, выровненных по границам строчек,
+// но не мельче minSize байт. Возвращает nil, если файл сжат (сжатый поток нельзя читать с середины)
+// или слишком мал для деления.
+func Split(path string, count int, minSize int64) ([]Chunk, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	count = int(min(int64(count), size/max(minSize, 1)))
+
+	if count < 2 || !info.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	header := make([]byte, magicBytesLength)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, err
+	}
+
+	if impl.DetectCompression(header, path) != impl.NoCompression {
+		return nil, nil
+	}
+
+	chunks := make([]Chunk, 0, count)
+	start := int64(0)
+
+	for i := 1; i < count && start < size; i++ {
+		end, err := lineStart(file, size*int64(i)/int64(count), size)
+		if err != nil {
+			return nil, err
+		}
+
+		if end > start {
+			chunks = append(chunks, Chunk{Start: start, End: end})
+			start = end
+		}
+	}
+
+	if start < size {
+		chunks = append(chunks, Chunk{Start: start, End: size})
+	}
+
+	return chunks, nil
+}
+
+// lineStart возвращает позицию начала первой строчки, которая начинается не раньше offset.
+func lineStart(file *os.File, offset, size int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+
+	// Если offset-1 это перевод строки, то offset уже является началом строчки.
+	reader := bufio.NewReader(io.NewSectionReader(file, offset-1, size-offset+1))
+
+	skipped := int64(0)
+
+	for {
+		part, err := reader.ReadSlice('\n')
+		skipped += int64(len(part))
+
+		switch {
+		case err == nil:
+			return offset - 1 + skipped, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			return size, nil
+		default:
+			return 0, err
+		}
+	}
+}
+
+// DetectFormat определяет формат лога по первым строчкам файла (см. impl.LineParser.Detect).
+// Формат определяется один раз для всего файла и передается в NewChunkReader для каждой его части,
+// иначе при --log-format auto разные части одного файла могли бы разбираться разными форматами.
+func DetectFormat(filepath string, parser impl.LineParser) (impl.LineParser, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return impl.LineParser{}, err
+	}
+
+	defer file.Close()
+
+	return parser.Detect(bufio.NewReaderSize(file, impl.BufferSize)), nil
+}
+
+// NewChunkReader создает Reader, который читает только часть файла chunk.
+// Формат parser не определяется заново по части файла: его нужно определить для всего файла через DetectFormat.
+func NewChunkReader(filepath string, chunk Chunk, parser impl.LineParser) (*Reader, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReaderSize(io.NewSectionReader(file, chunk.Start, chunk.End-chunk.Start), impl.BufferSize)
+
+	return &Reader{
+		reader:    reader,
+		closer:    file,
+		parser:    parser,
+		chunk:     &chunk,
+		chunkPath: filepath,
+	}, nil
+}
+
+// countLines возвращает количество строчек в первых size байтах файла.
+func countLines(path string, size int64) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer file.Close()
+
+	reader := io.LimitReader(file, size)
+	buffer := make([]byte, impl.BufferSize)
+	lines := int64(0)
+
+	for {
+		n, err := reader.Read(buffer)
+		lines += int64(bytes.Count(buffer[:n], []byte{'\n'}))
+
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+}
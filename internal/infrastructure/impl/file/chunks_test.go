@@ -0,0 +1,282 @@
+package file
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+)
+
+// benchmarkLines это количество строчек файла, на котором сравнивается чтение целиком и по частям.
+const benchmarkLines = 200_000
+
+// writeBenchmarkLog создает во временной директории лог в формате "combined" из benchmarkLines строчек
+// и возвращает путь к нему и его размер.
+func writeBenchmarkLog(b *testing.B) (string, int64) {
+	b.Helper()
+
+	path := filepath.Join(b.TempDir(), "access.log")
+
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	writer := bufio.NewWriter(f)
+
+	for i := range benchmarkLines {
+		_, _ = fmt.Fprintf(writer, `10.0.%d.%d - - [17/May/2015:08:%02d:%02d +0000] "GET /downloads/product_%d HTTP/1.1" `+
+			`%d %d "-" "Debian APT-HTTP/1.3 (0.8.16~exp12ubuntu10.21)"`+"\n",
+			i/256%256, i%256, i/60%60, i%60, i%100, []int{200, 304, 404, 500}[i%4], i%5000)
+	}
+
+	if err := writer.Flush(); err != nil {
+		b.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return path, info.Size()
+}
+
+// writeLog создает во временной директории файл с содержимым content и возвращает путь к нему.
+func writeLog(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		count   int
+		minSize int64
+		want    []Chunk
+	}{
+		{
+			name:    "newline at the chunk boundary",
+			content: "123456789\n123456789\n123456789\n123456789\n",
+			count:   2,
+			minSize: 1,
+			want:    []Chunk{{Start: 0, End: 20}, {Start: 20, End: 40}},
+		},
+		{
+			name:    "boundary inside a line",
+			content: "12345678901234\n1234\n1234\n",
+			count:   2,
+			minSize: 1,
+			want:    []Chunk{{Start: 0, End: 15}, {Start: 15, End: 25}},
+		},
+		{
+			name:    "last line without newline",
+			content: "aaaa\nbbbb\ncccc",
+			count:   2,
+			minSize: 1,
+			want:    []Chunk{{Start: 0, End: 10}, {Start: 10, End: 14}},
+		},
+		{
+			name:    "boundary inside the last line without newline",
+			content: "a\nbbbbbbbbbbbb",
+			count:   2,
+			minSize: 1,
+			want:    []Chunk{{Start: 0, End: 14}},
+		},
+		{
+			name:    "file smaller than the chunk size",
+			content: "aaaa\nbbbb\ncccc\n",
+			count:   4,
+			minSize: 1 << 20,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, tt.content)
+
+			chunks, err := Split(path, tt.count, tt.minSize)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, chunks)
+		})
+	}
+}
+
+func TestLineStart(t *testing.T) {
+	content := "aaaa\nbbbb\ncc"
+	path := writeLog(t, content)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	size := int64(len(content))
+
+	for offset, want := range map[int64]int64{0: 0, 1: 5, 4: 5, 5: 5, 6: 10, 10: 10, 11: size, 12: size} {
+		got, err := lineStart(f, offset, size)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "offset %d", offset)
+	}
+}
+
+func TestChunkReaderReadsEveryLine(t *testing.T) {
+	lines := []string{
+		`10.0.0.1 - - [17/May/2015:08:05:32 +0000] "GET /a HTTP/1.1" 200 10 "-" "curl"`,
+		`10.0.0.2 - - [17/May/2015:08:05:33 +0000] "GET /b HTTP/1.1" 200 20 "-" "curl"`,
+		`10.0.0.3 - - [17/May/2015:08:05:34 +0000] "GET /c HTTP/1.1" 200 30 "-" "curl"`,
+	}
+	path := writeLog(t, strings.Join(lines, "\n"))
+
+	chunks, err := Split(path, 3, 1)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+
+	total, err := readChunks(path, chunks)
+	require.NoError(t, err)
+	assert.Equal(t, len(lines), total)
+}
+
+// readAll читает записи read до конца и возвращает их количество.
+func readAll(read func() error) (int, error) {
+	lines := 0
+
+	for {
+		err := read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+
+		if err != nil {
+			return lines, err
+		}
+
+		lines++
+	}
+}
+
+// reportLines сообщает скорость обработки в строчках в секунду. Одна операция бенчмарка это весь файл.
+func reportLines(b *testing.B) {
+	b.ReportMetric(float64(b.N)*benchmarkLines/b.Elapsed().Seconds(), "lines/s")
+}
+
+// BenchmarkSequentialRead читает файл целиком одной горутиной через impl.ReadWithPattern.
+func BenchmarkSequentialRead(b *testing.B) {
+	path, size := writeBenchmarkLog(b)
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		f, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		reader := bufio.NewReaderSize(f, impl.BufferSize)
+
+		lines, err := readAll(func() error {
+			_, err := impl.ReadWithPattern(reader, impl.LineParser{})
+			return err
+		})
+
+		_ = f.Close()
+
+		if err != nil || lines != benchmarkLines {
+			b.Fatalf("read %d lines: %v", lines, err)
+		}
+	}
+
+	reportLines(b)
+}
+
+// BenchmarkChunkedRead делит файл на части через Split и читает каждую часть своей горутиной
+// через NewChunkReader. Split вызывается без ограничения на размер части, поэтому делится даже небольшой файл.
+func BenchmarkChunkedRead(b *testing.B) {
+	path, size := writeBenchmarkLog(b)
+
+	for _, workers := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			chunks, err := Split(path, workers, 1)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for range b.N {
+				if lines, err := readChunks(path, chunks); err != nil || lines != benchmarkLines {
+					b.Fatalf("read %d lines: %v", lines, err)
+				}
+			}
+
+			reportLines(b)
+		})
+	}
+}
+
+// readChunks читает части chunks файла path параллельно и возвращает общее количество строчек.
+func readChunks(path string, chunks []Chunk) (int, error) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+		errs  []error
+	)
+
+	for _, chunk := range chunks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			reader, err := NewChunkReader(path, chunk, impl.LineParser{})
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+
+				return
+			}
+
+			defer reader.Close()
+
+			lines, err := readAll(func() error {
+				_, err := reader.Read()
+				return err
+			})
+
+			mu.Lock()
+			total += lines
+			errs = append(errs, err)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return total, errors.Join(errs...)
+}
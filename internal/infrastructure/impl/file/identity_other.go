@@ -0,0 +1,13 @@
+//go:build !unix
+
+package file
+
+import (
+	"os"
+)
+
+// identity возвращает идентификатор файла. На платформах без inode файл опознается
+// только по хешу своего начала (input.Position.Head).
+func identity(_ os.FileInfo) string {
+	return ""
+}
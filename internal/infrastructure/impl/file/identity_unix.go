@@ -0,0 +1,18 @@
+//go:build unix
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// identity возвращает идентификатор файла: номер устройства и inode.
+func identity(info os.FileInfo) string {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino)
+	}
+
+	return ""
+}
@@ -2,31 +2,236 @@ package file
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
 	"os"
 
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
+)
+
+const (
+	// headSize это размер начала файла, по хешу которого обнаруживается перезапись файла на месте.
+	headSize = 4096
 )
 
 // Reader реализация интерфейса input.LogReader (для чтения из файлов).
+// Сжатые файлы (gzip, bzip2, zstd) распаковываются на лету.
 type Reader struct {
-	reader         *bufio.Reader // reader для буфферизированного чтения.
-	field, pattern string        // field и pattern нужны в случае фильтрации части лога по значению.
+	reader *bufio.Reader   // reader для буфферизированного чтения.
+	closer io.Closer       // closer закрывает распаковщик и сам файл.
+	parser impl.LineParser // parser разбирает и фильтрует строчки лога.
+
+	// Поля ниже используются только Reader, созданным через ResumeLogReader.
+	path     string
+	identity string
+	offset   int64
+	line     int64
+	resumed  bool
+
+	firstLine int64 // firstLine это количество строчек до начала чтения, см. FirstLine.
+
+	// Поля ниже используются только Reader, созданным через NewChunkReader.
+	chunk     *Chunk // chunk сбрасывается, когда firstLine подсчитан.
+	chunkPath string
 }
 
-func NewLogReader(filepath, field, pattern string) (*Reader, error) {
+func NewLogReader(filepath string, parser impl.LineParser) (*Reader, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, err
 	}
 
+	source, err := impl.Decompress(file, filepath)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReaderSize(source, impl.BufferSize)
+
 	return &Reader{
-		reader:  bufio.NewReader(file),
-		field:   field,
-		pattern: pattern,
+		reader: reader,
+		closer: source,
+		parser: parser.Detect(reader),
 	}, nil
 }
 
+// ResumeLogReader создает Reader, который продолжает чтение файла с позиции position,
+// если файл с тех пор не был заменен (другой inode) или усечен, и читает его сначала в обратном случае.
+// Такой Reader реализует input.Checkpointer и обрабатывает только строчки, заканчивающиеся переводом строки.
+func ResumeLogReader(filepath string, position input.Position, parser impl.LineParser) (*Reader, error) {
+	r := &Reader{
+		parser: parser,
+		path:   filepath,
+	}
+
+	positioned, err := r.open(position)
+	if err != nil {
+		return nil, err
+	}
+
+	if !positioned {
+		if _, err := r.open(input.Position{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// open открывает файл и переходит к позиции position, если она подходит к текущему содержимому файла,
+// или остается в начале файла в обратном случае. Возвращает false, если сжатый файл оказался короче
+// сохраненной позиции и его нужно открыть заново.
+func (r *Reader) open(position input.Position) (bool, error) {
+	if r.closer != nil {
+		_ = r.closer.Close()
+	}
+
+	file, err := os.Open(r.path)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return false, err
+	}
+
+	header := make([]byte, magicBytesLength)
+	n, _ := file.ReadAt(header, 0)
+	compressed := impl.DetectCompression(header[:n], r.path) != impl.NoCompression
+
+	r.identity, r.offset, r.line, r.firstLine, r.resumed = identity(info), 0, 0, 0, false
+
+	if position.Offset > 0 && position.Identity == r.identity && (compressed || position.Offset <= info.Size()) {
+		head, err := headHash(r.path, position.Offset)
+		if err != nil {
+			_ = file.Close()
+			return false, err
+		}
+
+		r.resumed = head == position.Head
+	}
+
+	if r.resumed && !compressed {
+		if _, err := file.Seek(position.Offset, io.SeekStart); err != nil {
+			_ = file.Close()
+			return false, err
+		}
+	}
+
+	source, err := impl.Decompress(file, r.path)
+	if err != nil {
+		_ = file.Close()
+		return false, err
+	}
+
+	r.reader, r.closer = bufio.NewReaderSize(source, impl.BufferSize), source
+
+	if r.resumed && compressed {
+		// Сжатый поток нельзя читать с середины, поэтому прочитанная часть пропускается.
+		if _, err := io.CopyN(io.Discard, r.reader, position.Offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				r.resumed = false
+				return false, nil
+			}
+
+			return false, err
+		}
+	}
+
+	if r.resumed {
+		r.offset, r.line, r.firstLine = position.Offset, position.Line, position.Line
+	}
+
+	r.parser = r.parser.Detect(r.reader)
+
+	return true, nil
+}
+
 func (r *Reader) Read() (*log.Record, error) {
-	return impl.ReadWithPattern(r.reader, r.field, r.pattern)
+	if r.path != "" {
+		offset := r.offset
+
+		record, err := impl.ReadCompleteLine(r.reader, r.parser, &r.offset)
+		if r.offset != offset {
+			r.line++
+		}
+
+		return record, err
+	}
+
+	return impl.ReadWithPattern(r.reader, r.parser)
+}
+
+// Format возвращает имя формата читаемого лога.
+func (r *Reader) Format() string {
+	return r.parser.Format
+}
+
+func (r *Reader) Position() (input.Position, error) {
+	head, err := headHash(r.path, r.offset)
+	if err != nil {
+		return input.Position{}, err
+	}
+
+	return input.Position{
+		Identity: r.identity,
+		Head:     head,
+		Offset:   r.offset,
+		Line:     r.line,
+	}, nil
+}
+
+func (r *Reader) Resumed() bool {
+	return r.resumed
+}
+
+// FirstLine возвращает количество строчек до позиции, с которой началось чтение.
+// Для части файла строчки до нее подсчитываются при первом вызове.
+func (r *Reader) FirstLine() (int64, error) {
+	if r.chunk == nil {
+		return r.firstLine, nil
+	}
+
+	lines, err := countLines(r.chunkPath, r.chunk.Start)
+	if err != nil {
+		return 0, err
+	}
+
+	r.firstLine, r.chunk = lines, nil
+
+	return r.firstLine, nil
+}
+
+func (r *Reader) Close() error {
+	return r.closer.Close()
+}
+
+// headHash возвращает хеш первых min(offset, headSize) байт содержимого файла (после распаковки).
+func headHash(filepath string, offset int64) (string, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := impl.Decompress(file, filepath)
+	if err != nil {
+		_ = file.Close()
+		return "", err
+	}
+
+	defer source.Close()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, source, min(offset, headSize)); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
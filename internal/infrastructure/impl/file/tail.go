@@ -0,0 +1,178 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+)
+
+const (
+	magicBytesLength = 4
+)
+
+// TailReader реализация интерфейса input.LogReader, которая ведет себя как `tail -F`:
+// дойдя до конца файла, она ждет новых строчек, а при ротации (усечении файла
+// или замене его другим файлом) переоткрывает его и читает с начала.
+// Read возвращает io.EOF только после отмены ctx.
+type TailReader struct {
+	ctx      context.Context
+	path     string
+	interval time.Duration // interval задает период опроса файла на наличие новых данных.
+
+	file   *os.File
+	info   os.FileInfo
+	reader *bufio.Reader
+	closer io.Closer // closer закрывает распаковщик сжатого файла.
+	offset int64     // offset это количество прочитанных из текущего файла байт.
+
+	static  bool   // static выставляется для сжатых файлов, которые не дописываются.
+	pending string // pending хранит недописанную строчку.
+
+	parser impl.LineParser // parser разбирает и фильтрует строчки лога.
+}
+
+func NewTailReader(ctx context.Context, path string, parser impl.LineParser, interval time.Duration) (*TailReader, error) {
+	t := &TailReader{
+		ctx:      ctx,
+		path:     path,
+		interval: interval,
+		parser:   parser,
+	}
+
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *TailReader) open() error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	buffered := bufio.NewReaderSize(file, impl.BufferSize)
+	header, _ := buffered.Peek(magicBytesLength)
+
+	t.file, t.info, t.offset, t.pending = file, info, 0, ""
+	t.static = impl.DetectCompression(header, t.path) != impl.NoCompression
+
+	if !t.static {
+		t.reader, t.closer = buffered, io.NopCloser(nil)
+		t.parser = t.parser.Detect(t.reader)
+
+		return nil
+	}
+
+	source, err := impl.Decompress(io.NopCloser(buffered), t.path)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	t.reader, t.closer = bufio.NewReaderSize(source, impl.BufferSize), source
+	t.parser = t.parser.Detect(t.reader)
+
+	return nil
+}
+
+func (t *TailReader) Read() (*log.Record, error) {
+	for {
+		line, err := t.reader.ReadString('\n')
+		t.offset += int64(len(line))
+		t.pending += line
+
+		if err == nil {
+			line, t.pending = t.pending, ""
+			return t.parser.ParseLine(line)
+		}
+
+		if !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		last, rotated, err := t.checkRotation()
+		if err != nil {
+			return nil, err
+		}
+
+		if last != "" {
+			return t.parser.ParseLine(last)
+		}
+
+		if rotated {
+			continue
+		}
+
+		select {
+		case <-t.ctx.Done():
+			return nil, io.EOF
+		case <-time.After(t.interval):
+		}
+	}
+}
+
+// checkRotation переоткрывает файл, если он был заменен или усечен, и возвращает true, если чтение нужно
+// продолжить без ожидания. Как и `tail -F`, перед переходом к новому файлу checkRotation дочитывает замененный файл:
+// пока в нем есть непрочитанные строчки, возвращается true без переоткрытия, а недописанная последняя строчка
+// возвращается в last. Сжатые файлы не переоткрываются.
+func (t *TailReader) checkRotation() (last string, rotated bool, err error) {
+	if t.static {
+		return "", false, nil
+	}
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// Во время ротации файла по старому пути может ненадолго не быть.
+		return "", false, nil
+	}
+
+	switch {
+	case !os.SameFile(t.info, info):
+		if current, err := t.file.Stat(); err == nil && current.Size() > t.offset {
+			return "", true, nil
+		}
+
+		if t.pending != "" {
+			last, t.pending = t.pending, ""
+			return last, false, nil
+		}
+
+		_ = t.file.Close()
+
+		return "", true, t.open()
+	case info.Size() < t.offset:
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return "", false, err
+		}
+
+		t.info, t.offset, t.pending = info, 0, ""
+		t.reader.Reset(t.file)
+
+		return "", true, nil
+	}
+
+	return "", false, nil
+}
+
+func (t *TailReader) Close() error {
+	return errors.Join(t.closer.Close(), t.file.Close())
+}
+
+// Format возвращает имя формата читаемого лога.
+func (t *TailReader) Format() string {
+	return t.parser.Format
+}
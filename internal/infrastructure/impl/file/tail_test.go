@@ -0,0 +1,56 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+)
+
+// tailLine возвращает строчку в формате "combined" с запросом к path.
+func tailLine(path string) string {
+	return fmt.Sprintf(`10.0.0.1 - - [17/May/2015:08:05:32 +0000] "GET %s HTTP/1.1" 200 10 "-" "curl"`, path)
+}
+
+func TestTailReaderDrainsReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	require.NoError(t, os.WriteFile(path, []byte(tailLine("/1")+"\n"), 0o600))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reader, err := NewTailReader(ctx, path, impl.LineParser{}, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	defer reader.Close()
+
+	record, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "/1", record.Request.Path)
+
+	old, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+
+	defer old.Close()
+
+	// Ротация: файл переименовывается, в него еще дописываются строчки, а по старому пути создается новый файл.
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "access.log.1")))
+	require.NoError(t, os.WriteFile(path, []byte(tailLine("/4")+"\n"), 0o600))
+
+	_, err = old.WriteString(tailLine("/2") + "\n" + tailLine("/3"))
+	require.NoError(t, err)
+
+	for _, want := range []string{"/2", "/3", "/4"} {
+		record, err := reader.Read()
+		require.NoError(t, err)
+		assert.Equal(t, want, record.Request.Path)
+	}
+}
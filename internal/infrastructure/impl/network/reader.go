@@ -2,56 +2,187 @@ package network
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
 	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
 )
 
 // Reader реализация интерфейса input.LogReader (для чтения по сети).
+// Тело ответа обрабатывается по мере скачивания, а при обрыве соединения
+// чтение продолжается с места остановки. Сжатые ответы (gzip, bzip2, zstd) распаковываются на лету.
 type Reader struct {
-	reader         *bufio.Reader // reader для буфферизированного чтения.
-	field, pattern string        // field и pattern нужны в случае фильтрации части лога по значению.
+	reader *bufio.Reader   // reader для буфферизированного чтения.
+	closer io.Closer       // closer закрывает распаковщик и соединение.
+	parser impl.LineParser // parser разбирает и фильтрует строчки лога.
+
+	// Поля ниже используются только Reader, созданным через ResumeReader.
+	source     *source
+	checkpoint bool
+	offset     int64
+	line       int64
+	firstLine  int64
+	resumed    bool
 }
 
 var (
 	ErrUnexpectedCode = errors.New("unexpected code")
 )
 
-func NewReader(address, field, pattern string) (*Reader, error) {
-	req, err := http.NewRequest(http.MethodGet, address, http.NoBody)
+func NewReader(address string, parser impl.LineParser, options Options) (*Reader, error) {
+	body, err := newSource(address, options, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return newReader(body, address, parser)
+}
+
+// ResumeReader создает Reader, который продолжает чтение удаленного лога с позиции position
+// через HTTP Range, если ETag (или Last-Modified) лога не изменился, и читает его сначала в обратном случае.
+// Такой Reader реализует input.Checkpointer. Сжатые логи всегда читаются сначала,
+// поскольку сжатый поток нельзя читать с середины.
+func ResumeReader(address string, position input.Position, parser impl.LineParser,
+	options Options) (*Reader, error) {
+	var (
+		body    *source
+		err     error
+		resumed = position.Offset > 0 && position.Identity != ""
+	)
+
+	if resumed {
+		body, err = newSource(address, options, position.Offset, position.Identity)
+		resumed = !errors.Is(err, ErrSourceChanged)
+	}
+
+	if !resumed {
+		body, err = newSource(address, options, 0, "")
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	r, err := newReader(body, address, parser)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	r.source, r.resumed = body, resumed && r.checkpoint
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, ErrUnexpectedCode
+	if r.resumed {
+		r.offset, r.line, r.firstLine = position.Offset, position.Line, position.Line
 	}
 
-	// Такое чтение необходимо только, чтобы линтер не жаловался.
-	respBody, err := io.ReadAll(resp.Body)
+	return r, nil
+}
+
+func newReader(body *source, address string, parser impl.LineParser) (*Reader, error) {
+	source, compression, err := impl.DecompressDetected(body, urlPath(address))
 	if err != nil {
+		_ = body.Close()
 		return nil, err
 	}
 
+	reader := bufio.NewReaderSize(source, impl.BufferSize)
+
 	return &Reader{
-		reader:  bufio.NewReader(bytes.NewBuffer(respBody)),
-		field:   field,
-		pattern: pattern,
+		reader:     reader,
+		closer:     source,
+		parser:     parser.Detect(reader),
+		checkpoint: compression == impl.NoCompression,
 	}, nil
 }
 
 func (r *Reader) Read() (*log.Record, error) {
-	return impl.ReadWithPattern(r.reader, r.field, r.pattern)
+	if r.source != nil {
+		offset := r.offset
+
+		record, err := impl.ReadCompleteLine(r.reader, r.parser, &r.offset)
+		if r.offset != offset {
+			r.line++
+		}
+
+		return record, err
+	}
+
+	return impl.ReadWithPattern(r.reader, r.parser)
+}
+
+func (r *Reader) Position() (input.Position, error) {
+	if !r.checkpoint {
+		return input.Position{}, nil
+	}
+
+	return input.Position{
+		Identity: r.source.validator,
+		Offset:   r.offset,
+		Line:     r.line,
+	}, nil
+}
+
+// FirstLine возвращает количество строчек до позиции, с которой продолжилось чтение.
+func (r *Reader) FirstLine() (int64, error) {
+	return r.firstLine, nil
+}
+
+func (r *Reader) Resumed() bool {
+	return r.resumed
+}
+
+// Format возвращает имя формата читаемого лога.
+func (r *Reader) Format() string {
+	return r.parser.Format
+}
+
+func (r *Reader) Close() error {
+	return r.closer.Close()
+}
+
+// Probe проверяет доступность URL, отправляя HTTP-запрос методом HEAD.
+// Серверы, которые не поддерживают HEAD, считаются доступными.
+func Probe(address string, options Options) error {
+	ctx := context.Background()
+
+	if options.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, options.ConnectTimeout+options.ReadTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, address, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := options.Client().Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed, resp.StatusCode == http.StatusNotImplemented:
+		return nil
+	case resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrUnexpectedCode, resp.Status)
+	}
+
+	return nil
+}
+
+func urlPath(address string) string {
+	if parsed, err := url.Parse(address); err == nil {
+		return parsed.Path
+	}
+
+	return address
 }
@@ -0,0 +1,266 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+var (
+	ErrSourceChanged = errors.New("remote log changed while it was being read")
+	ErrReadTimeout   = errors.New("read timeout exceeded")
+)
+
+// Options содержит настройки сетевого чтения логов.
+type Options struct {
+	ConnectTimeout time.Duration // Максимальное время установки соединения.
+	ReadTimeout    time.Duration // Максимальное время ожидания очередной порции данных.
+	Retries        int           // Количество повторных попыток после обрыва соединения.
+	Backoff        time.Duration // Начальная задержка между попытками, удваивается после каждой из них.
+}
+
+// Client создает HTTP-клиент с таймаутом на установку соединения.
+// Прозрачная распаковка ответов отключена: смещения в Range должны
+// считаться по байтам тела в том виде, в котором его отдает сервер.
+func (o Options) Client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+
+	if o.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: o.ConnectTimeout}).DialContext
+		transport.TLSHandshakeTimeout = o.ConnectTimeout
+	}
+
+	if o.ReadTimeout > 0 {
+		transport.ResponseHeaderTimeout = o.ReadTimeout
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+func (o Options) backoff(attempt int) time.Duration {
+	delay := o.Backoff
+	if delay <= 0 {
+		delay = defaultBackoff
+	}
+
+	for range attempt {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
+
+// source это потоковое тело HTTP-ответа, которое при обрыве соединения
+// переоткрывается с той же позиции через заголовок Range.
+type source struct {
+	client  *http.Client
+	address string
+	options Options
+
+	body   io.ReadCloser
+	cancel context.CancelFunc
+
+	offset    int64  // Количество уже прочитанных байт.
+	validator string // ETag (или Last-Modified) первого ответа, нужен для проверки того, что файл не изменился.
+}
+
+// newSource открывает удаленный лог, начиная с байта offset. Если offset больше нуля,
+// validator должен совпадать с тем, что сервер вернул при чтении первой части лога,
+// иначе возвращается ErrSourceChanged.
+func newSource(address string, options Options, offset int64, validator string) (*source, error) {
+	s := &source{
+		client:    options.Client(),
+		address:   address,
+		options:   options,
+		offset:    offset,
+		validator: validator,
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// open отправляет GET-запрос, начиная с текущей позиции s.offset.
+func (s *source) open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.address, http.NoBody)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if s.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", s.offset))
+
+		if s.validator != "" {
+			req.Header.Set("If-Range", s.validator)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if s.upToDate(resp) {
+		resp.Body.Close()
+		s.body, s.cancel = http.NoBody, cancel
+
+		return nil
+	}
+
+	if err := s.accept(resp); err != nil {
+		resp.Body.Close()
+		cancel()
+
+		return err
+	}
+
+	s.body = resp.Body
+	s.cancel = cancel
+
+	return nil
+}
+
+// upToDate проверяет, что сервер отказал в Range-запросе только потому,
+// что после s.offset в логе нет новых данных.
+func (s *source) upToDate(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || s.offset == 0 {
+		return false
+	}
+
+	total, found := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes */")
+	if !found {
+		return false
+	}
+
+	size, err := strconv.ParseInt(total, 10, 64)
+
+	return err == nil && size == s.offset
+}
+
+// accept проверяет ответ сервера и, если сервер проигнорировал Range,
+// пропускает уже прочитанную часть тела.
+func (s *source) accept(resp *http.Response) error {
+	validator := responseValidator(resp)
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && s.offset > 0:
+		return nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && s.offset > 0:
+		// Лог стал короче уже прочитанной части.
+		return ErrSourceChanged
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: %s", ErrUnexpectedCode, resp.Status)
+	case s.offset == 0:
+		s.validator = validator
+		return nil
+	case s.validator == "" || validator != s.validator:
+		return ErrSourceChanged
+	}
+
+	_, err := io.CopyN(io.Discard, resp.Body, s.offset)
+
+	return err
+}
+
+// responseValidator возвращает ETag ответа, а если его нет, то Last-Modified.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag
+	}
+
+	return resp.Header.Get("Last-Modified")
+}
+
+func (s *source) Read(p []byte) (int, error) {
+	n, err := s.read(p)
+
+	for attempt := 0; s.retryable(n, err) && attempt < s.options.Retries; attempt++ {
+		s.closeBody()
+		time.Sleep(s.options.backoff(attempt))
+
+		if err = s.open(); err == nil {
+			n, err = s.read(p)
+		}
+	}
+
+	s.offset += int64(n)
+
+	// Ошибку после частично прочитанных данных вернет следующий вызов Read,
+	// который и попробует переоткрыть соединение.
+	if n > 0 && err != nil && !errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	return n, err
+}
+
+func (s *source) retryable(n int, err error) bool {
+	return n == 0 && err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, ErrSourceChanged)
+}
+
+// read читает очередную порцию данных, прерывая запрос, если данные
+// не пришли за options.ReadTimeout.
+func (s *source) read(p []byte) (int, error) {
+	if s.body == nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if s.options.ReadTimeout <= 0 {
+		return s.body.Read(p)
+	}
+
+	var timedOut atomic.Bool
+
+	cancel := s.cancel
+	timer := time.AfterFunc(s.options.ReadTimeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+
+	n, err := s.body.Read(p)
+	timer.Stop()
+
+	if err != nil && !errors.Is(err, io.EOF) && timedOut.Load() {
+		err = ErrReadTimeout
+	}
+
+	return n, err
+}
+
+func (s *source) closeBody() {
+	if s.body == nil {
+		return
+	}
+
+	_ = s.body.Close()
+	s.cancel()
+	s.body = nil
+}
+
+func (s *source) Close() error {
+	s.closeBody()
+	return nil
+}
@@ -0,0 +1,46 @@
+package stdin
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/impl"
+)
+
+// Reader реализация интерфейса input.LogReader (для чтения из стандартного ввода).
+// Сжатый ввод (gzip, bzip2, zstd) распаковывается на лету.
+type Reader struct {
+	reader *bufio.Reader   // reader для буфферизированного чтения.
+	closer io.Closer       // closer закрывает распаковщик, сам os.Stdin остается открытым.
+	parser impl.LineParser // parser разбирает и фильтрует строчки лога.
+}
+
+func NewReader(parser impl.LineParser) (*Reader, error) {
+	source, err := impl.Decompress(io.NopCloser(os.Stdin), "")
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReaderSize(source, impl.BufferSize)
+
+	return &Reader{
+		reader: reader,
+		closer: source,
+		parser: parser.Detect(reader),
+	}, nil
+}
+
+func (r *Reader) Read() (*log.Record, error) {
+	return impl.ReadWithPattern(r.reader, r.parser)
+}
+
+// Format возвращает имя формата читаемого лога.
+func (r *Reader) Format() string {
+	return r.parser.Format
+}
+
+func (r *Reader) Close() error {
+	return r.closer.Close()
+}
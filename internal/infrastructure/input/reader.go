@@ -1,9 +1,44 @@
 package input
 
 import (
+	"io"
+
 	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/log"
 )
 
 type LogReader interface {
+	io.Closer
 	Read() (*log.Record, error)
 }
+
+// FormatReader это LogReader, который сообщает формат читаемого лога (в том числе определенный автоматически).
+type FormatReader interface {
+	LogReader
+	Format() string
+}
+
+// Position это позиция чтения лога, которая сохраняется между запусками.
+type Position struct {
+	Identity string `json:"identity"`       // Устройство и inode файла или ETag удаленного лога.
+	Head     string `json:"head,omitempty"` // Хеш начала файла, нужен для обнаружения его перезаписи на месте.
+	Offset   int64  `json:"offset"`         // Количество байт в полностью прочитанных строчках.
+	Line     int64  `json:"line"`           // Количество полностью прочитанных строчек.
+}
+
+// LineNumberer это LogReader, который начинает чтение не с первой строчки лога
+// (например, с сохраненной позиции или с середины файла).
+type LineNumberer interface {
+	LogReader
+	// FirstLine возвращает количество строчек лога до начала чтения.
+	FirstLine() (int64, error)
+}
+
+// Checkpointer это LogReader, который умеет продолжать чтение с сохраненной позиции.
+type Checkpointer interface {
+	LogReader
+	// Position возвращает позицию после последней прочитанной строчки.
+	Position() (Position, error)
+	// Resumed сообщает, продолжилось ли чтение с сохраненной позиции. Если лог был
+	// усечен или заменен, чтение начинается сначала, и накопленную статистику нужно сбросить.
+	Resumed() bool
+}
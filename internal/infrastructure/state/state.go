@@ -0,0 +1,79 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/es-debug/backend-academy-2024-go-template/internal/domain/models/analyzer"
+	"github.com/es-debug/backend-academy-2024-go-template/internal/infrastructure/input"
+)
+
+// Checkpoint хранит позицию чтения входных данных и статистику, накопленную по ним до этой позиции.
+type Checkpoint struct {
+	Position   input.Position       `json:"position"`
+	Statistics *analyzer.Statistics `json:"statistics"`
+}
+
+// State это содержимое файла состояния, которое позволяет при следующем запуске
+// обработать только новые строчки логов.
+type State struct {
+	Options     string                 `json:"options"`     // Настройки, с которыми была собрана статистика.
+	Checkpoints map[string]*Checkpoint `json:"checkpoints"` // Позиции чтения по путям входных данных.
+}
+
+// Load читает состояние из файла path. Если файла нет или статистика в нем была собрана
+// с другими настройками (options), возвращается пустое состояние.
+func Load(path, options string) (*State, error) {
+	empty := &State{
+		Options:     options,
+		Checkpoints: make(map[string]*Checkpoint),
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return empty, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+
+	if state.Options != options || state.Checkpoints == nil {
+		return empty, nil
+	}
+
+	return state, nil
+}
+
+// Save атомарно записывает состояние в файл path.
+func (s *State) Save(path string) error {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(content); err != nil {
+		_ = temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,149 @@
+package util
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	recursiveWildcard = "**"
+	globMetaSymbols   = `*?[\`
+)
+
+// Glob возвращает пути файлов, соответствующих pattern.
+// В отличие от filepath.Glob поддерживает сегмент "**", совпадающий с любым количеством директорий,
+// и возвращает только файлы, директории в результат не попадают.
+// Если pattern указывает на директорию, возвращаются все файлы внутри нее (рекурсивно).
+func Glob(pattern string) ([]string, error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		pattern = filepath.Join(pattern, recursiveWildcard)
+	}
+
+	if !strings.Contains(pattern, recursiveWildcard) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return onlyFiles(matches), nil
+	}
+
+	patternSegments := splitPath(pattern)
+
+	var matches []string
+
+	err := filepath.WalkDir(globRoot(pattern), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == globRoot(pattern) {
+				return err
+			}
+
+			// Недоступные поддиректории пропускаются, как и в filepath.Glob.
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		if matchSegments(patternSegments, splitPath(path)) {
+			matches = append(matches, path)
+		}
+
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return matches, err
+}
+
+// MatchPath проверяет, соответствует ли путь шаблону pattern.
+// Шаблон может совпадать как с путем целиком, так и с любым его окончанием:
+// "*.tmp" совпадает с "/var/log/a.tmp", а "*/debug/*" — с "/srv/logs/debug/access.log".
+func MatchPath(pattern, path string) bool {
+	patternSegments := splitPath(pattern)
+	pathSegments := splitPath(path)
+
+	for i := range pathSegments {
+		if matchSegments(patternSegments, pathSegments[i:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globRoot возвращает самую длинную часть pattern без специальных символов,
+// с которой можно начинать обход директорий.
+func globRoot(pattern string) string {
+	segments := strings.Split(pattern, string(filepath.Separator))
+
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, globMetaSymbols) {
+			root := strings.Join(segments[:i], string(filepath.Separator))
+
+			switch {
+			case root == "" && filepath.IsAbs(pattern):
+				return string(filepath.Separator)
+			case root == "":
+				return "."
+			}
+
+			return root
+		}
+	}
+
+	return pattern
+}
+
+func splitPath(path string) []string {
+	path = filepath.Clean(path)
+
+	return strings.FieldsFunc(filepath.ToSlash(path), func(r rune) bool {
+		return r == '/'
+	})
+}
+
+// matchSegments сопоставляет сегменты пути с сегментами шаблона.
+// Сегмент "**" совпадает с любым количеством сегментов пути, в том числе с нулем.
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	if pattern[0] == recursiveWildcard {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+
+	if matched, err := filepath.Match(pattern[0], path[0]); err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], path[1:])
+}
+
+func onlyFiles(paths []string) []string {
+	files := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+
+	return files
+}